@@ -0,0 +1,57 @@
+//
+// Copyright (c) 2019-present Codist <countstarlight@gmail.com>. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+// Written by Codist <countstarlight@gmail.com>, October 2026
+//
+
+package main
+
+import (
+	"fmt"
+	"github.com/countstarlight/homo/cmd/webview/config"
+	"github.com/countstarlight/homo/module/com"
+	"github.com/sirupsen/logrus"
+	"github.com/urfave/cli"
+)
+
+var commands = []cli.Command{
+	{
+		Name:      "encrypt",
+		Usage:     "encrypt a value to put in config file, eg. VOICE_API_SECRET = enc:...",
+		ArgsUsage: "<value>",
+		Flags: []cli.Flag{
+			cli.BoolFlag{
+				Name:  "gen-key",
+				Usage: "generate a random key file first, key file is set by env HOMO_SECRET_KEY_FILE, default: conf/secret.key",
+			},
+		},
+		Action: encrypt,
+	},
+}
+
+func encrypt(c *cli.Context) error {
+	if c.Bool("gen-key") {
+		file, err := config.GenerateSecretKey()
+		if err != nil {
+			return err
+		}
+		logrus.Infof("已生成密钥文件 %s ，请妥善保管", file)
+		if c.NArg() == 0 {
+			return nil
+		}
+	}
+	if c.NArg() != 1 {
+		return fmt.Errorf("需要一个要加密的值")
+	}
+	key, err := config.LoadSecretKey()
+	if err != nil {
+		return err
+	}
+	value, err := com.Encrypt(key, c.Args().First())
+	if err != nil {
+		return fmt.Errorf("加密出错: %s", err.Error())
+	}
+	fmt.Println(config.EncryptedPrefix + value)
+	return nil
+}
@@ -8,11 +8,13 @@
 package config
 
 import (
+	"fmt"
 	"github.com/countstarlight/homo/module/audio"
 	"github.com/countstarlight/homo/module/com"
 	"github.com/go-ini/ini"
 	"github.com/sirupsen/logrus"
 	"github.com/urfave/cli"
+	"net"
 	"os"
 	"os/exec"
 	"path"
@@ -24,11 +26,13 @@ import (
 
 var (
 	//App settings
-	AppPath string
-	LogPath string
+	AppPath  string
+	LogPath  string
+	DataPath string // Root of all writable paths
 
 	// Mode
 	DebugMode      bool
+	DryRun         bool // Check config and exit
 	SilenceMode    bool
 	OfflineMode    bool
 	InterruptMode  bool
@@ -36,19 +40,27 @@ var (
 
 	// Global config
 	Cfg      *ini.File
-	ConfFile string
+	ConfFile string // Format is decided by extension: .ini, .json or .toml
+	Profile  string // Named profile overrides sections, eg. [log.prod]
+
+	// Keys set by included files, like "log.LEVEL"
+	includedKeys map[string]bool
+	// Writable paths set explicitly in config file, key like "log.ROOT_PATH" -> path
+	pinnedKeys map[string]string
 
 	// PortAudio
-	RawDir   string
-	InputRaw string
-	InputWav string
+	RawDir      string
+	InputRaw    string
+	InputWav    string
+	AudioDevice int
+	SampleRate  int
+	NoiseGate   int
 
 	//sphinx
-	HMMDirEn        string
-	DictFileEn      string
-	LMFileEn        string
-	SphinxLogFile   string
-	RecordThreshold int
+	HMMDirEn      string
+	DictFileEn    string
+	LMFileEn      string
+	SphinxLogFile string
 
 	// Nlu
 	ConversationAPI string
@@ -67,6 +79,19 @@ var (
 	TTSDir     string
 	TTSOutFile string
 
+	// Pipeline
+	WakeWords   []string
+	PipelineASR string
+
+	// Health check of external dependencies
+	HealthInterval int // seconds, 0 to disable
+	HealthTimeout  int // seconds
+	HealthTargets  []string
+
+	// Diagnostics server of pprof and runtime state
+	DebugAddr  string // disabled if empty
+	DebugToken string
+
 	// Flag
 	IsPlayingVoice bool
 	WakeUpd        bool
@@ -74,7 +99,6 @@ var (
 
 	// Lock
 	VoicePlayMutex sync.Mutex
-	SphinxLoop     sync.WaitGroup // Make sphinx keep capturing audio input
 	WakeUpWait     sync.WaitGroup
 
 	//Auto convert raw pcm buffer to wav
@@ -86,6 +110,13 @@ const (
 	AppVersion = "0.0.1"
 )
 
+// Engines can be used for each stage of voice pipeline
+var pipelineEngines = map[string][]string{
+	"ASR": {"baidu"},
+	"NLU": {"local", "core"},
+	"TTS": {"baidu", "none"},
+}
+
 // execPath returns the executable path.
 func execPath() (string, error) {
 	file, err := exec.LookPath(os.Args[0])
@@ -98,7 +129,7 @@ func execPath() (string, error) {
 func init() {
 	RawToWav = false
 	AnalyticalMode = false
-	RecordThreshold = 50000
+	SampleRate = 16000
 
 	var err error
 	if AppPath, err = execPath(); err != nil {
@@ -124,96 +155,353 @@ func WorkDir() (string, error) {
 	return AppPath[:i], nil
 }
 
+// LoadConfig loads config file and checks environment, creates missing paths
+// and exits if anything is wrong.
 func LoadConfig() {
+	if errs := loadConfig(false); len(errs) > 0 {
+		for _, err := range errs {
+			logrus.Error(err.Error())
+		}
+		logrus.Fatalf("检查配置发现 %d 个问题，请按照提示修复后重试", len(errs))
+	}
+
+	// Update config file
+	UpdateConfigFile()
+}
+
+// CheckConfig loads config file and checks environment like LoadConfig,
+// but changes nothing and returns all problems found.
+func CheckConfig() []error {
+	return loadConfig(true)
+}
+
+func loadConfig(dryRun bool) (errs []error) {
 	workDir, err := WorkDir()
 	if err != nil {
-		logrus.Fatalf("Fail to get work directory: %s", err.Error())
+		return []error{fmt.Errorf("Fail to get work directory: %s", err.Error())}
+	}
+	// Use the first one found if not specified, conf/app.ini if none found
+	if len(ConfFile) == 0 {
+		ConfFile = path.Join(workDir, "conf/app.ini")
+		for _, ext := range []string{".ini", ".json", ".toml"} {
+			if file := path.Join(workDir, "conf/app"+ext); com.IsFile(file) {
+				ConfFile = file
+				break
+			}
+		}
 	}
-	ConfFile = path.Join(workDir, "conf/app.ini")
 
 	if !com.IsFile(ConfFile) {
-		logrus.Fatalf("没有找到配置文件 %s , 如果是第一次运行，请拷贝一份 conf/example_app.ini 到 conf/app.ini", ConfFile)
+		return []error{fmt.Errorf("没有找到配置文件 %s , 如果是第一次运行，请拷贝一份 conf/example_app.ini 到 conf/app.ini", ConfFile)}
 	}
 
-	Cfg, err = ini.Load(ConfFile)
-	if err != nil {
-		logrus.Fatalf("解析配置文件 %s 出错: %s", ConfFile, err.Error())
+	if Cfg, includedKeys, err = parseConfigFile(); err != nil {
+		return []error{err}
 	}
 
-	Cfg.NameMapper = ini.AllCapsUnderscore
-
-	// Load log config
-	sec := Cfg.Section("log")
-	LogPath = sec.Key("ROOT_PATH").MustString(path.Join(workDir, "log"))
-
-	// Create log path
-	if !com.PathExists(LogPath) {
-		err := os.MkdirAll(LogPath, os.ModePerm)
-		if err != nil {
-			logrus.Fatalf("Create path %s failed: %s", LogPath, err.Error())
+	if len(Profile) > 0 {
+		if !hasProfile() {
+			return []error{fmt.Errorf("配置文件 %s 中没有找到配置方案 [%s] 对应的配置段，例如 [log.%s]", ConfFile, Profile, Profile)}
 		}
+		logrus.Infof("使用配置方案: %s", Profile)
 	}
 
-	// Load PortAudio config
-	sec = Cfg.Section("portaudio")
-	RawDir = sec.Key("RAW_DIR").MustString(path.Join(workDir, "tmp/record"))
-	InputRaw = sec.Key("INPUT_RAW").MustString(path.Join(workDir, "tmp/record/input.pcm"))
-	InputWav = sec.Key("INPUT_WAV").MustString(path.Join(workDir, "tmp/record/input.wav"))
-
-	// Create ram dir path
-	if !com.PathExists(RawDir) {
-		err := os.MkdirAll(RawDir, os.ModePerm)
-		if err != nil {
-			logrus.Fatalf("Create path %s failed: %s", RawDir, err.Error())
+	// Load data config, all writable paths are under it by default,
+	// point it to a writable partition if root filesystem is read-only
+	sec := Cfg.Section(SectionName("data"))
+	// HOMO_DATA_DIR overrides config file
+	DataPath = os.Getenv("HOMO_DATA_DIR")
+	if len(DataPath) == 0 {
+		DataPath = stringValue(sec, "ROOT_PATH", workDir)
+	}
+
+	// Old versions wrote absolute default paths under work directory into config file
+	pinnedKeys = make(map[string]string)
+	writablePath := func(section, key, def, legacy string) string {
+		v := Cfg.Section(SectionName(section)).Key(key).Value()
+		if len(v) == 0 || path.Clean(v) == path.Clean(legacy) {
+			return def
 		}
+		pinnedKeys[section+"."+key] = v
+		if DataPath != workDir && !strings.HasPrefix(path.Clean(v)+"/", path.Clean(DataPath)+"/") {
+			logrus.Warnf("配置项 %s.%s = %s 不在数据目录 %s 下，不会随 [data] ROOT_PATH 或 HOMO_DATA_DIR 改变", section, key, v, DataPath)
+		}
+		return v
 	}
 
-	// Load sphinx config
-	sec = Cfg.Section("sphinx")
-	HMMDirEn = sec.Key("EN_HMM_DIR").MustString(path.Join(workDir, "sphinx/en-us/en-us"))
+	// Load log config
+	LogPath = writablePath("log", "ROOT_PATH", path.Join(DataPath, "log"), path.Join(workDir, "log"))
+
+	// Load log level, record threshold and nlu, tts engine of pipeline,
+	// they can be changed by ReloadConfig
+	setSettings(loadSettings(Cfg, &errs))
 
-	if !com.PathExists(HMMDirEn) {
-		logrus.Fatalf("没有找到离线唤醒的英文语音模型 %s ，请按照 https://homo.codist.me/docs/dataset/ 下载并放置对应的数据，或在配置文件中指定自定义的目录", HMMDirEn)
+	// Load PortAudio config
+	sec = Cfg.Section(SectionName("portaudio"))
+	RawDir = writablePath("portaudio", "RAW_DIR", path.Join(DataPath, "tmp/record"), path.Join(workDir, "tmp/record"))
+	InputRaw = writablePath("portaudio", "INPUT_RAW", path.Join(DataPath, "tmp/record/input.pcm"), path.Join(workDir, "tmp/record/input.pcm"))
+	InputWav = writablePath("portaudio", "INPUT_WAV", path.Join(DataPath, "tmp/record/input.wav"), path.Join(workDir, "tmp/record/input.wav"))
+	AudioDevice = intValue(sec, "DEVICE", audio.DefaultDevice, &errs)
+	SampleRate = intValue(sec, "SAMPLE_RATE", 16000, &errs)
+	NoiseGate = intValue(sec, "NOISE_GATE", 0, &errs)
+
+	// Baidu asr only supports 8000Hz and 16000Hz
+	if SampleRate != 8000 && SampleRate != 16000 {
+		errs = append(errs, fmt.Errorf("采样率 portaudio.SAMPLE_RATE 只能是 8000 或 16000，当前为 %d", SampleRate))
+	}
+	if NoiseGate < 0 || NoiseGate > 32767 {
+		errs = append(errs, fmt.Errorf("噪声门限 portaudio.NOISE_GATE 应在 0 到 32767 之间，当前为 %d", NoiseGate))
 	}
 
-	DictFileEn = sec.Key("EN_DICT_FILE").MustString(path.Join(workDir, "sphinx/homo/homo.dic"))
-	LMFileEn = sec.Key("EN_LM_FILE").MustString(path.Join(workDir, "sphinx/homo/homo.lm.bin"))
-	RecordThreshold = sec.Key("RECORD_THRESHOLD").MustInt(50000)
-	SphinxLogFile = sec.Key("LOG_FILE").MustString(path.Join(LogPath, "sphinx.log"))
+	// Load sphinx config
+	sec = Cfg.Section(SectionName("sphinx"))
+	HMMDirEn = stringValue(sec, "EN_HMM_DIR", path.Join(workDir, "sphinx/en-us/en-us"))
+	DictFileEn = stringValue(sec, "EN_DICT_FILE", path.Join(workDir, "sphinx/homo/homo.dic"))
+	LMFileEn = stringValue(sec, "EN_LM_FILE", path.Join(workDir, "sphinx/homo/homo.lm.bin"))
+	SphinxLogFile = writablePath("sphinx", "LOG_FILE", path.Join(LogPath, "sphinx.log"), path.Join(workDir, "log/sphinx.log"))
 
 	// Load NLU config
-	sec = Cfg.Section("nlu")
-	ConversationAPI = sec.Key("CONVERSATION_API").MustString("http://localhost:5005/conversations/default/respond")
-	ParseAPI = sec.Key("PARSE_API").MustString("http://localhost:5000/parse")
-	NluProject = sec.Key("PROJECT").MustString("rasa")
-	NluModel = sec.Key("MODEL").MustString("ini")
+	sec = Cfg.Section(SectionName("nlu"))
+	ConversationAPI = stringValue(sec, "CONVERSATION_API", "http://localhost:5005/conversations/default/respond")
+	ParseAPI = stringValue(sec, "PARSE_API", "http://localhost:5000/parse")
+	NluProject = stringValue(sec, "PROJECT", "rasa")
+	NluModel = stringValue(sec, "MODEL", "ini")
 
 	// Load baidu config
-	sec = Cfg.Section("baidu")
-	BaiduASRAPI = sec.Key("ASR_API").MustString("http://vop.baidu.com/server_api")
-	BaiduTTSAPI = sec.Key("TTS_API").MustString("http://tsn.baidu.com/text2audio")
-	BaiduVoiceAuthUrl = sec.Key("VOICE_AUTH_URL").MustString("https://openapi.baidu.com/oauth/2.0/token")
-	BaiduVoiceAPIKey = sec.Key("VOICE_API_KEY").MustString("MDNsII2jkUtbF729GQOZt7FS")
-	BaiduVoiceAPISecret = sec.Key("VOICE_API_SECRET").MustString("0vWCVCLsbWHMSH1wjvxaDq4VmvCZM2O9")
+	sec = Cfg.Section(SectionName("baidu"))
+	BaiduASRAPI = stringValue(sec, "ASR_API", "http://vop.baidu.com/server_api")
+	BaiduTTSAPI = stringValue(sec, "TTS_API", "http://tsn.baidu.com/text2audio")
+	BaiduVoiceAuthUrl = stringValue(sec, "VOICE_AUTH_URL", "https://openapi.baidu.com/oauth/2.0/token")
+	BaiduVoiceAPIKey = stringValue(sec, "VOICE_API_KEY", "MDNsII2jkUtbF729GQOZt7FS")
+	BaiduVoiceAPISecret = stringValue(sec, "VOICE_API_SECRET", "0vWCVCLsbWHMSH1wjvxaDq4VmvCZM2O9")
 
 	// Load tts config
-	sec = Cfg.Section("tts")
-	TTSDir = sec.Key("TTS_DIR").MustString(path.Join(workDir, "tmp/tts"))
-	TTSOutFile = sec.Key("TTS_OUT_FILE").MustString(path.Join(workDir, "tmp/tts/tmp.wav"))
+	TTSDir = writablePath("tts", "TTS_DIR", path.Join(DataPath, "tmp/tts"), path.Join(workDir, "tmp/tts"))
+	TTSOutFile = writablePath("tts", "TTS_OUT_FILE", path.Join(DataPath, "tmp/tts/tmp.wav"), path.Join(workDir, "tmp/tts/tmp.wav"))
+
+	// Load pipeline config, voice goes through wake up -> asr -> nlu -> tts
+	sec = Cfg.Section(SectionName("pipeline"))
+	WakeWords = stringsValue(sec, "WAKE_WORDS")
+	if len(WakeWords) == 0 {
+		WakeWords = []string{"homo", "como"}
+	}
+	// Words in sphinx dictionary are lower case
+	for i := range WakeWords {
+		WakeWords[i] = strings.ToLower(WakeWords[i])
+	}
+	PipelineASR = pipelineEngine(sec, "ASR", "baidu", &errs)
+
+	// Load health config, external dependencies are checked periodically if not offline
+	sec = Cfg.Section(SectionName("health"))
+	HealthInterval = intValue(sec, "INTERVAL", 60, &errs)
+	HealthTimeout = intValue(sec, "TIMEOUT", 5, &errs)
+	HealthTargets = stringsValue(sec, "TARGETS")
+	if HealthInterval < 0 {
+		errs = append(errs, fmt.Errorf("检查间隔 health.INTERVAL 不能小于 0，当前为 %d", HealthInterval))
+	}
+	if HealthTimeout <= 0 {
+		errs = append(errs, fmt.Errorf("检查超时 health.TIMEOUT 应大于 0，当前为 %d", HealthTimeout))
+	}
 
-	// Create path
-	if !com.PathExists(TTSDir) {
-		err := os.MkdirAll(TTSDir, os.ModePerm)
+	// Load debug config, diagnostics server is only started if ADDR is set
+	sec = Cfg.Section(SectionName("debug"))
+	DebugAddr = sec.Key("ADDR").Value()
+	DebugToken = sec.Key("TOKEN").Value()
+	if len(DebugAddr) > 0 {
+		// Profiles and cmdline must not be readable by other local users
+		if len(DebugToken) == 0 {
+			errs = append(errs, fmt.Errorf("启用诊断服务 debug.ADDR 时必须设置 debug.TOKEN"))
+		}
+		if _, _, err := net.SplitHostPort(DebugAddr); err != nil {
+			errs = append(errs, fmt.Errorf("诊断服务地址 debug.ADDR 无效: %s", err.Error()))
+		}
+	}
+
+	// Check environment, paths are created if not dry run
+	for _, f := range Preflight(dryRun) {
+		errs = append(errs, f)
+	}
+	return errs
+}
+
+// parseConfigFile parses ConfFile and files included by it into a new ini.File,
+// files matching INCLUDE glob in default section are merged in order of name,
+// their keys override keys in ConfFile, included keys like "log.LEVEL" are returned.
+func parseConfigFile() (*ini.File, map[string]bool, error) {
+	cfg, err := parseFile(ConfFile)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	included := make(map[string]bool)
+	if pattern := com.ExpandEnv(cfg.Section("").Key("INCLUDE").Value()); len(pattern) > 0 {
+		if !filepath.IsAbs(pattern) {
+			pattern = filepath.Join(filepath.Dir(ConfFile), pattern)
+		}
+		// Matches are sorted by name
+		files, err := filepath.Glob(pattern)
 		if err != nil {
-			logrus.Fatalf("Create path %s failed: %s", TTSDir, err.Error())
+			return nil, nil, fmt.Errorf("解析 INCLUDE %s 出错: %s", pattern, err.Error())
+		}
+		for _, file := range files {
+			inc, err := parseFile(file)
+			if err != nil {
+				return nil, nil, err
+			}
+			for _, sec := range inc.Sections() {
+				for _, key := range sec.Keys() {
+					if sec.Name() == ini.DEFAULT_SECTION && key.Name() == "INCLUDE" {
+						continue
+					}
+					if err := setKey(cfg, sec.Name(), key.Name(), key.Value()); err != nil {
+						return nil, nil, fmt.Errorf("合并配置文件 %s 出错: %s", file, err.Error())
+					}
+					included[sec.Name()+"."+key.Name()] = true
+				}
+			}
+			logrus.Debugf("合并配置文件 %s", file)
 		}
 	}
 
-	// Update config file
-	UpdateConfigFile()
+	secrets, err := decryptValues(cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	cfg.NameMapper = ini.AllCapsUnderscore
+	// Values are resolved once here instead of by ValueMapper, Key.String() would expand
+	// %(name)s in env or decrypted values again and panics if name is not a key.
+	// Read them by stringValue, intValue and stringsValue.
+	for _, sec := range cfg.Sections() {
+		for _, key := range sec.Keys() {
+			// Decrypted values are used as is
+			if text, ok := secrets[key.Value()]; ok {
+				key.SetValue(text)
+				continue
+			}
+			// Expand environment variables like ${HOME} or ${HOMO_DATA:-/data}
+			key.SetValue(com.ExpandEnv(key.Value()))
+		}
+	}
+	return cfg, included, nil
+}
+
+// parseFile parses a config file into a new ini.File,
+// format is decided by file extension: .json, .toml or ini by default.
+func parseFile(file string) (cfg *ini.File, err error) {
+	switch strings.ToLower(path.Ext(file)) {
+	case ".json":
+		cfg, err = loadJSON(file)
+	case ".toml":
+		cfg, err = loadTOML(file)
+	default:
+		cfg, err = ini.Load(file)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("解析配置文件 %s 出错: %s", file, err.Error())
+	}
+	return cfg, nil
+}
+
+// stringValue returns value of key in sec, or def if it is not set.
+func stringValue(sec *ini.Section, key, def string) string {
+	if v := sec.Key(key).Value(); len(v) > 0 {
+		return v
+	}
+	return def
+}
+
+// stringsValue returns comma separated items of key in sec, empty items are dropped.
+func stringsValue(sec *ini.Section, key string) []string {
+	var items []string
+	for _, item := range strings.Split(sec.Key(key).Value(), ",") {
+		if item = strings.TrimSpace(item); len(item) > 0 {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// setKey sets value of key in section of cfg, section is created if not exists.
+// Key() would return key of parent section if not in child section, NewKey is used instead.
+func setKey(cfg *ini.File, section, key, value string) error {
+	_, err := cfg.Section(section).NewKey(key, value)
+	return err
+}
+
+// intValue returns int value of key in sec, or def if it is not set,
+// error is appended to errs if value is not a valid int.
+func intValue(sec *ini.Section, key string, def int, errs *[]error) int {
+	value := strings.TrimSpace(sec.Key(key).Value())
+	if len(value) == 0 {
+		return def
+	}
+	v, err := strconv.Atoi(value)
+	if err != nil {
+		*errs = append(*errs, fmt.Errorf("配置项 %s.%s 不是有效的整数: %s", sec.Name(), key, value))
+		return def
+	}
+	return v
+}
+
+// pipelineEngine returns engine of stage in sec, or def if it is not set,
+// error is appended to errs if engine is not supported.
+func pipelineEngine(sec *ini.Section, stage, def string, errs *[]error) string {
+	engine := strings.ToLower(stringValue(sec, stage, def))
+	if !com.IfStringInArray(engine, pipelineEngines[stage]) {
+		*errs = append(*errs, fmt.Errorf("配置项 %s.%s 不支持 [%s]，可选: %s", sec.Name(), stage, engine, strings.Join(pipelineEngines[stage], ", ")))
+	}
+	return engine
+}
+
+// SectionName returns name of the section overridden by current profile,
+// or name itself if no profile is set or profile does not override it.
+// Keys missing in profile section are inherited from its parent section.
+func SectionName(name string) string {
+	return sectionName(Cfg, name)
+}
+
+// sectionName is SectionName of sections in cfg
+func sectionName(cfg *ini.File, name string) string {
+	if len(Profile) == 0 {
+		return name
+	}
+	if _, err := cfg.GetSection(name + "." + Profile); err != nil {
+		return name
+	}
+	return name + "." + Profile
+}
+
+// hasProfile returns true if any section is overridden by current profile.
+func hasProfile() bool {
+	for _, name := range Cfg.SectionStrings() {
+		if strings.HasSuffix(name, "."+Profile) {
+			return true
+		}
+	}
+	return false
 }
 
 func UpdateConfigFile() {
+	// Only ini config file can be updated
+	if ext := strings.ToLower(path.Ext(ConfFile)); ext == ".json" || ext == ".toml" {
+		return
+	}
+
+	// Keep config file as shipped when profile is used,
+	// resolved values would overwrite the base sections
+	if len(Profile) > 0 {
+		return
+	}
+
+	// Config file may be on a read-only filesystem
+	if !com.IsWritable(ConfFile) {
+		logrus.Warnf("配置文件 %s 不可写，跳过更新配置文件", ConfFile)
+		return
+	}
+
+	// Only fill keys not set, values set are kept as is,
+	// environment variables in them are not expanded
 	cfg := ini.Empty()
 	if com.IsFile(ConfFile) {
 		// Keeps custom settings if there is already something.
@@ -222,43 +510,65 @@ func UpdateConfigFile() {
 		}
 	}
 
+	// Writable paths under data.ROOT_PATH are left empty and resolved at load time,
+	// so that changing data.ROOT_PATH or HOMO_DATA_DIR moves all of them
+
+	settings := CurrentSettings()
+
 	// Update log config
-	cfg.Section("log").Key("ROOT_PATH").SetValue(LogPath)
+	setDefault(cfg, "log", "LEVEL", settings.LogLevel.String())
 
 	// Update PortAudio config
-	cfg.Section("portaudio").Key("RAW_DIR").SetValue(RawDir)
-	cfg.Section("portaudio").Key("INPUT_RAW").SetValue(InputRaw)
-	cfg.Section("portaudio").Key("INPUT_WAV").SetValue(InputWav)
+	setDefault(cfg, "portaudio", "DEVICE", strconv.Itoa(AudioDevice))
+	setDefault(cfg, "portaudio", "SAMPLE_RATE", strconv.Itoa(SampleRate))
+	setDefault(cfg, "portaudio", "NOISE_GATE", strconv.Itoa(NoiseGate))
 
 	// Update sphinx config
-	cfg.Section("sphinx").Key("EN_HMM_DIR").SetValue(HMMDirEn)
-	cfg.Section("sphinx").Key("EN_DICT_FILE").SetValue(DictFileEn)
-	cfg.Section("sphinx").Key("EN_LM_FILE").SetValue(LMFileEn)
-	cfg.Section("sphinx").Key("RECORD_THRESHOLD").SetValue(strconv.Itoa(RecordThreshold))
-	cfg.Section("sphinx").Key("LOG_FILE").SetValue(SphinxLogFile)
+	setDefault(cfg, "sphinx", "EN_HMM_DIR", HMMDirEn)
+	setDefault(cfg, "sphinx", "EN_DICT_FILE", DictFileEn)
+	setDefault(cfg, "sphinx", "EN_LM_FILE", LMFileEn)
+	setDefault(cfg, "sphinx", "RECORD_THRESHOLD", strconv.Itoa(settings.RecordThreshold))
 
 	// Update nlu config
-	cfg.Section("nlu").Key("CONVERSATION_API").SetValue(ConversationAPI)
-	cfg.Section("nlu").Key("PARSE_API").SetValue(ParseAPI)
-	cfg.Section("nlu").Key("PROJECT").SetValue(NluProject)
-	cfg.Section("nlu").Key("MODEL").SetValue(NluModel)
+	setDefault(cfg, "nlu", "CONVERSATION_API", ConversationAPI)
+	setDefault(cfg, "nlu", "PARSE_API", ParseAPI)
+	setDefault(cfg, "nlu", "PROJECT", NluProject)
+	setDefault(cfg, "nlu", "MODEL", NluModel)
 
 	// Update baidu config
-	cfg.Section("baidu").Key("ASR_API").SetValue(BaiduASRAPI)
-	cfg.Section("baidu").Key("TTS_API").SetValue(BaiduTTSAPI)
-	cfg.Section("baidu").Key("VOICE_AUTH_URL").SetValue(BaiduVoiceAuthUrl)
-	cfg.Section("baidu").Key("VOICE_API_KEY").SetValue(BaiduVoiceAPIKey)
-	cfg.Section("baidu").Key("VOICE_API_SECRET").SetValue(BaiduVoiceAPISecret)
-
-	// Update tts config
-	cfg.Section("tts").Key("TTS_DIR").SetValue(TTSDir)
-	cfg.Section("tts").Key("TTS_OUT_FILE").SetValue(TTSOutFile)
+	setDefault(cfg, "baidu", "ASR_API", BaiduASRAPI)
+	setDefault(cfg, "baidu", "TTS_API", BaiduTTSAPI)
+	setDefault(cfg, "baidu", "VOICE_AUTH_URL", BaiduVoiceAuthUrl)
+	setDefault(cfg, "baidu", "VOICE_API_KEY", BaiduVoiceAPIKey)
+	setDefault(cfg, "baidu", "VOICE_API_SECRET", BaiduVoiceAPISecret)
+
+	// Update pipeline config
+	setDefault(cfg, "pipeline", "WAKE_WORDS", strings.Join(WakeWords, ", "))
+	setDefault(cfg, "pipeline", "ASR", PipelineASR)
+	setDefault(cfg, "pipeline", "NLU", settings.PipelineNLU)
+	setDefault(cfg, "pipeline", "TTS", settings.PipelineTTS)
+
+	// Update health config
+	setDefault(cfg, "health", "INTERVAL", strconv.Itoa(HealthInterval))
+	setDefault(cfg, "health", "TIMEOUT", strconv.Itoa(HealthTimeout))
 
 	if err := cfg.SaveTo(ConfFile); err != nil {
 		logrus.Fatalf("Update config file failed: %s", err.Error())
 	}
 }
 
+// setDefault sets value of key in section if it is not set,
+// keys set by included files are not written back.
+func setDefault(cfg *ini.File, section, key, value string) {
+	if includedKeys[section+"."+key] {
+		return
+	}
+	k := cfg.Section(section).Key(key)
+	if len(k.Value()) == 0 {
+		k.SetValue(value)
+	}
+}
+
 func Terminal(c *cli.Context) error {
 	logrus.Infof("退出，开始结束PortAudio...")
 	return audio.PaTerminate()
@@ -0,0 +1,22 @@
+//
+// Copyright (c) 2019-present Codist <countstarlight@gmail.com>. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+// Written by Codist <countstarlight@gmail.com>, October 2026
+//
+
+//go:build !windows
+// +build !windows
+
+package config
+
+import "syscall"
+
+// diskFree returns available bytes of the filesystem path is on.
+func diskFree(path string) (uint64, error) {
+	var st syscall.Statfs_t
+	if err := syscall.Statfs(path, &st); err != nil {
+		return 0, err
+	}
+	return st.Bavail * uint64(st.Bsize), nil
+}
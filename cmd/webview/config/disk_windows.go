@@ -0,0 +1,15 @@
+//
+// Copyright (c) 2019-present Codist <countstarlight@gmail.com>. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+// Written by Codist <countstarlight@gmail.com>, October 2026
+//
+
+package config
+
+import "errors"
+
+// diskFree is not supported on windows, disk space check is skipped.
+func diskFree(path string) (uint64, error) {
+	return 0, errors.New("not supported")
+}
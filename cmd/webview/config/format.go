@@ -0,0 +1,125 @@
+//
+// Copyright (c) 2019-present Codist <countstarlight@gmail.com>. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+// Written by Codist <countstarlight@gmail.com>, October 2026
+//
+
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/countstarlight/homo/module/toml"
+	"github.com/go-ini/ini"
+	"io/ioutil"
+	"strconv"
+	"strings"
+)
+
+// loadJSON loads config in json format into ini.File, eg.
+//
+//	{"log": {"ROOT_PATH": "log"}, "pipeline": {"WAKE_WORDS": ["homo", "como"]}}
+//
+// Each top level object is a section, arrays are joined with comma.
+func loadJSON(file string) (*ini.File, error) {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	var sections map[string]map[string]interface{}
+	d := json.NewDecoder(bytes.NewReader(data))
+	d.UseNumber()
+	if err := d.Decode(&sections); err != nil {
+		return nil, err
+	}
+
+	cfg := ini.Empty()
+	for name, keys := range sections {
+		// Empty object is still a section
+		cfg.Section(name)
+		for key, value := range keys {
+			v, err := jsonValue(value)
+			if err != nil {
+				return nil, fmt.Errorf("%s.%s: %s", name, key, err.Error())
+			}
+			if err := setKey(cfg, name, key, v); err != nil {
+				return nil, fmt.Errorf("%s.%s: %s", name, key, err.Error())
+			}
+		}
+	}
+	return cfg, nil
+}
+
+func jsonValue(value interface{}) (string, error) {
+	switch v := value.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return v, nil
+	case json.Number:
+		return v.String(), nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	case []interface{}:
+		items := make([]string, 0, len(v))
+		for _, item := range v {
+			if _, ok := item.([]interface{}); ok {
+				return "", fmt.Errorf("nested array is not supported")
+			}
+			s, err := jsonValue(item)
+			if err != nil {
+				return "", err
+			}
+			items = append(items, s)
+		}
+		return joinItems(items)
+	}
+	return "", fmt.Errorf("unsupported value %v", value)
+}
+
+// loadTOML loads config in toml format into ini.File, only tables with
+// strings, numbers, booleans and single line arrays of them are supported, eg.
+//
+//	[pipeline]
+//	WAKE_WORDS = ["homo", "como"]
+//
+// Table [log.prod] is section log.prod, arrays are joined with comma.
+func loadTOML(file string) (*ini.File, error) {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	values, err := toml.Parse(data)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := ini.Empty()
+	for _, v := range values {
+		var value string
+		if v.Array {
+			if value, err = joinItems(v.Items); err != nil {
+				return nil, fmt.Errorf("line %d: %s", v.Line, err.Error())
+			}
+		} else {
+			value = v.Items[0]
+		}
+		if err := setKey(cfg, v.Table, v.Key, value); err != nil {
+			return nil, fmt.Errorf("line %d: %s", v.Line, err.Error())
+		}
+	}
+	return cfg, nil
+}
+
+// joinItems joins items of array with comma, items containing comma are
+// rejected as they would be split by stringsValue.
+func joinItems(items []string) (string, error) {
+	for _, item := range items {
+		if strings.Contains(item, ",") {
+			return "", fmt.Errorf("array item can not contain comma: %q", item)
+		}
+	}
+	return strings.Join(items, ", "), nil
+}
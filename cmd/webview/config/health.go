@@ -0,0 +1,54 @@
+//
+// Copyright (c) 2019-present Codist <countstarlight@gmail.com>. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+// Written by Codist <countstarlight@gmail.com>, October 2026
+//
+
+package config
+
+import (
+	"github.com/countstarlight/homo/module/health"
+	"strings"
+)
+
+// External dependencies are named by their config key
+const (
+	DepBaiduAuth = "baidu.VOICE_AUTH_URL"
+	DepBaiduASR  = "baidu.ASR_API"
+	DepBaiduTTS  = "baidu.TTS_API"
+	DepNLU       = "nlu.PARSE_API"
+	DepNLUCore   = "nlu.CONVERSATION_API"
+)
+
+// ExternalDeps returns external dependencies used by current pipeline,
+// and extra ones in health.TARGETS.
+func ExternalDeps() []health.Target {
+	targets := []health.Target{
+		{Name: DepBaiduAuth, Addr: BaiduVoiceAuthUrl},
+		{Name: DepBaiduASR, Addr: BaiduASRAPI},
+	}
+	settings := CurrentSettings()
+	if settings.PipelineTTS == "baidu" {
+		targets = append(targets, health.Target{Name: DepBaiduTTS, Addr: BaiduTTSAPI})
+	}
+	if settings.PipelineNLU == "core" {
+		targets = append(targets, health.Target{Name: DepNLUCore, Addr: ConversationAPI})
+	} else {
+		targets = append(targets, health.Target{Name: DepNLU, Addr: ParseAPI})
+	}
+	for _, addr := range HealthTargets {
+		if addr = strings.TrimSpace(addr); len(addr) > 0 {
+			targets = append(targets, health.Target{Name: "health.TARGETS " + addr, Addr: addr})
+		}
+	}
+	return targets
+}
+
+// NLUDep returns name of external dependency used by nlu engine of pipeline
+func NLUDep() string {
+	if CurrentSettings().PipelineNLU == "core" {
+		return DepNLUCore
+	}
+	return DepNLU
+}
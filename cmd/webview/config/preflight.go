@@ -0,0 +1,158 @@
+//
+// Copyright (c) 2019-present Codist <countstarlight@gmail.com>. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+// Written by Codist <countstarlight@gmail.com>, October 2026
+//
+
+package config
+
+import (
+	"fmt"
+	"github.com/countstarlight/homo/module/com"
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+	"time"
+)
+
+const (
+	// Recorded audio and tts output are written to data path
+	minDiskFree = 50 << 20
+	// Baidu api over https fails if clock is earlier than this
+	minClockYear = 2019
+)
+
+// PreflightFailure is a failed check with hint to fix it.
+type PreflightFailure struct {
+	Check string
+	Err   error
+	Hint  string
+}
+
+func (f PreflightFailure) Error() string {
+	return fmt.Sprintf("[%s] %s，%s", f.Check, f.Err.Error(), f.Hint)
+}
+
+// Preflight checks environment with loaded config before start,
+// returns all failures instead of stopping at the first one.
+// Missing paths are created unless in dry run.
+func Preflight(dryRun bool) []PreflightFailure {
+	var failures []PreflightFailure
+
+	// Writable paths
+	writableHint := "如果根文件系统是只读的，请在配置文件中设置 [data] ROOT_PATH 或环境变量 HOMO_DATA_DIR 指向可写的数据分区"
+	for _, d := range []struct{ key, dir string }{
+		{"log.ROOT_PATH", LogPath},
+		{"portaudio.RAW_DIR", RawDir},
+		{"portaudio.INPUT_RAW", path.Dir(InputRaw)},
+		{"portaudio.INPUT_WAV", path.Dir(InputWav)},
+		{"sphinx.LOG_FILE", path.Dir(SphinxLogFile)},
+		{"tts.TTS_DIR", TTSDir},
+		{"tts.TTS_OUT_FILE", path.Dir(TTSOutFile)},
+	} {
+		if err := makeWritableDir(d.dir, dryRun); err != nil {
+			hint := writableHint
+			if v, ok := pinnedKeys[d.key]; ok {
+				hint = fmt.Sprintf("配置项 %s 固定为 %s，不受 [data] ROOT_PATH 和 HOMO_DATA_DIR 影响，请清空它或改为可写路径", d.key, v)
+			}
+			failures = append(failures, PreflightFailure{Check: d.key, Err: err, Hint: hint})
+		}
+	}
+
+	// Wake up model
+	modelHint := "请按照 https://homo.codist.me/docs/dataset/ 下载并放置对应的数据，或在配置文件中指定自定义的路径"
+	for _, m := range []struct{ key, file string }{
+		{"sphinx.EN_HMM_DIR", HMMDirEn},
+		{"sphinx.EN_DICT_FILE", DictFileEn},
+		{"sphinx.EN_LM_FILE", LMFileEn},
+	} {
+		if !com.PathExists(m.file) {
+			failures = append(failures, PreflightFailure{
+				Check: m.key,
+				Err:   fmt.Errorf("没有找到离线唤醒的语音模型 %s", m.file),
+				Hint:  modelHint,
+			})
+		}
+	}
+
+	// Wake words must be in dictionary of wake up model
+	if words, err := dictWords(DictFileEn); err == nil {
+		for _, w := range WakeWords {
+			if !words[w] {
+				failures = append(failures, PreflightFailure{
+					Check: "pipeline.WAKE_WORDS",
+					Err:   fmt.Errorf("唤醒词 [%s] 不在词典 %s 中", w, DictFileEn),
+					Hint:  "请将唤醒词及其发音添加到词典中，或在配置文件中指定包含它的词典",
+				})
+			}
+		}
+	}
+
+	// Disk space, skipped if not supported on this platform
+	if free, err := diskFree(DataPath); err == nil && free < minDiskFree {
+		failures = append(failures, PreflightFailure{
+			Check: "data.ROOT_PATH",
+			Err:   fmt.Errorf("%s 所在分区剩余空间 %dMB 不足 %dMB", DataPath, free>>20, minDiskFree>>20),
+			Hint:  "请清理磁盘空间或将 [data] ROOT_PATH 指向空间充足的分区",
+		})
+	}
+
+	// Clock
+	if now := time.Now(); now.Year() < minClockYear {
+		failures = append(failures, PreflightFailure{
+			Check: "clock",
+			Err:   fmt.Errorf("系统时间 %s 不正确", now.Format("2006-01-02 15:04:05")),
+			Hint:  "请同步系统时间(如启用 NTP)，否则无法通过 HTTPS 调用语音服务",
+		})
+	}
+
+	return failures
+}
+
+// dictWords returns words in sphinx dictionary file,
+// alternative pronunciations like "homo(2)" are counted as "homo".
+func dictWords(file string) (map[string]bool, error) {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	words := make(map[string]bool)
+	for _, line := range strings.Split(string(data), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		w := fields[0]
+		if i := strings.Index(w, "("); i > 0 {
+			w = w[:i]
+		}
+		words[strings.ToLower(w)] = true
+	}
+	return words, nil
+}
+
+// makeWritableDir creates dir if it does not exist and makes sure it is writable,
+// in dry run only checks the nearest existing parent of dir is writable.
+func makeWritableDir(dir string, dryRun bool) error {
+	if !com.PathExists(dir) {
+		if dryRun {
+			parent := dir
+			for !com.PathExists(parent) && parent != path.Dir(parent) {
+				parent = path.Dir(parent)
+			}
+			if !com.IsWritable(parent) {
+				return fmt.Errorf("无法创建目录 %s，上级目录 %s 不可写", dir, parent)
+			}
+			return nil
+		}
+		if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+			return fmt.Errorf("创建目录 %s 失败: %s", dir, err.Error())
+		}
+	}
+	if !com.IsWritable(dir) {
+		return fmt.Errorf("目录 %s 不可写", dir)
+	}
+	return nil
+}
@@ -0,0 +1,106 @@
+//
+// Copyright (c) 2019-present Codist <countstarlight@gmail.com>. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+// Written by Codist <countstarlight@gmail.com>, October 2026
+//
+
+package config
+
+import (
+	"fmt"
+	"github.com/go-ini/ini"
+	"github.com/sirupsen/logrus"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+)
+
+// Settings can be changed by ReloadConfig while running,
+// read them by CurrentSettings every time instead of keeping a copy.
+type Settings struct {
+	LogLevel        logrus.Level
+	RecordThreshold int
+	PipelineNLU     string
+	PipelineTTS     string
+}
+
+var (
+	settingsMutex sync.RWMutex
+	settings      = Settings{
+		LogLevel:        logrus.InfoLevel,
+		RecordThreshold: 50000,
+		PipelineNLU:     "local",
+		PipelineTTS:     "baidu",
+	}
+)
+
+// CurrentSettings returns settings in use, safe to call from any goroutine.
+func CurrentSettings() Settings {
+	settingsMutex.RLock()
+	defer settingsMutex.RUnlock()
+	return settings
+}
+
+func setSettings(s Settings) {
+	settingsMutex.Lock()
+	settings = s
+	settingsMutex.Unlock()
+	// Debug mode keeps printing everything
+	if !DebugMode {
+		logrus.SetLevel(s.LogLevel)
+	}
+}
+
+// loadSettings reads settings from cfg, errors are appended to errs.
+func loadSettings(cfg *ini.File, errs *[]error) Settings {
+	var s Settings
+	var err error
+	if s.LogLevel, err = logrus.ParseLevel(stringValue(cfg.Section(sectionName(cfg, "log")), "LEVEL", "info")); err != nil {
+		*errs = append(*errs, fmt.Errorf("解析日志等级 log.LEVEL 出错: %s", err.Error()))
+		s.LogLevel = logrus.InfoLevel
+	}
+	s.RecordThreshold = intValue(cfg.Section(sectionName(cfg, "sphinx")), "RECORD_THRESHOLD", 50000, errs)
+	sec := cfg.Section(sectionName(cfg, "pipeline"))
+	s.PipelineNLU = pipelineEngine(sec, "NLU", "local", errs)
+	s.PipelineTTS = pipelineEngine(sec, "TTS", "baidu", errs)
+	return s
+}
+
+// ReloadConfig reloads settings can be changed without restart from config file:
+// log level, record threshold and nlu, tts engine of pipeline.
+// Nothing is changed if any of them is invalid.
+func ReloadConfig() error {
+	cfg, _, err := parseConfigFile()
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	s := loadSettings(cfg, &errs)
+	if len(errs) > 0 {
+		for _, err := range errs[1:] {
+			logrus.Error(err.Error())
+		}
+		return errs[0]
+	}
+	setSettings(s)
+	return nil
+}
+
+// WatchReload reloads config when SIGHUP received.
+func WatchReload() {
+	c := make(chan os.Signal, 1)
+	signal.Notify(c, syscall.SIGHUP)
+	for range c {
+		logrus.Infof("收到 SIGHUP，开始重新加载配置文件 %s", ConfFile)
+		if err := ReloadConfig(); err != nil {
+			logrus.Errorf("重新加载配置文件失败，保持当前配置: %s", err.Error())
+			continue
+		}
+		s := CurrentSettings()
+		logrus.Infof("重新加载配置文件完成，日志等级[%s] 录音阈值[%d] NLU[%s] TTS[%s]",
+			s.LogLevel, s.RecordThreshold, s.PipelineNLU, s.PipelineTTS)
+	}
+}
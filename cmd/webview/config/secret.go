@@ -0,0 +1,104 @@
+//
+// Copyright (c) 2019-present Codist <countstarlight@gmail.com>. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+// Written by Codist <countstarlight@gmail.com>, October 2026
+//
+
+package config
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"github.com/countstarlight/homo/module/com"
+	"github.com/go-ini/ini"
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+)
+
+// EncryptedPrefix marks an encrypted value in config file, eg.
+//
+//	API_KEY = enc:<base64>
+//
+// Values can be generated by `homo-webview encrypt <value>`
+const EncryptedPrefix = "enc:"
+
+// SecretKeyFile returns path of the key file used to decrypt config values,
+// default conf/secret.key under work directory.
+func SecretKeyFile() (string, error) {
+	if file := os.Getenv("HOMO_SECRET_KEY_FILE"); len(file) > 0 {
+		return file, nil
+	}
+	workDir, err := WorkDir()
+	if err != nil {
+		return "", err
+	}
+	return path.Join(workDir, "conf/secret.key"), nil
+}
+
+// LoadSecretKey reads key file and derives the AES key from it
+func LoadSecretKey() ([]byte, error) {
+	file, err := SecretKeyFile()
+	if err != nil {
+		return nil, err
+	}
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("读取密钥文件 %s 出错: %s", file, err.Error())
+	}
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 {
+		return nil, fmt.Errorf("密钥文件 %s 为空", file)
+	}
+	return com.DeriveKey(data), nil
+}
+
+// GenerateSecretKey creates a random key file, never overwrites an existing one
+func GenerateSecretKey() (string, error) {
+	file, err := SecretKeyFile()
+	if err != nil {
+		return "", err
+	}
+	if com.PathExists(file) {
+		return "", fmt.Errorf("密钥文件 %s 已存在", file)
+	}
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	if err := ioutil.WriteFile(file, []byte(hex.EncodeToString(buf)+"\n"), 0600); err != nil {
+		return "", fmt.Errorf("写入密钥文件 %s 出错: %s", file, err.Error())
+	}
+	return file, nil
+}
+
+// decryptValues decrypts all encrypted values in cfg, returns plain text keyed
+// by raw value. Key file is only read if there is any encrypted value.
+func decryptValues(cfg *ini.File) (map[string]string, error) {
+	secrets := make(map[string]string)
+	var key []byte
+	for _, sec := range cfg.Sections() {
+		for _, k := range sec.Keys() {
+			value := k.Value()
+			if !strings.HasPrefix(value, EncryptedPrefix) {
+				continue
+			}
+			if key == nil {
+				var err error
+				if key, err = LoadSecretKey(); err != nil {
+					return nil, err
+				}
+			}
+			text, err := com.Decrypt(key, strings.TrimPrefix(value, EncryptedPrefix))
+			if err != nil {
+				return nil, fmt.Errorf("解密配置项 [%s] %s 出错，请检查密钥文件: %s", sec.Name(), k.Name(), err.Error())
+			}
+			secrets[value] = text
+		}
+	}
+	return secrets, nil
+}
@@ -10,13 +10,17 @@ package main
 import (
 	"fmt"
 	"github.com/countstarlight/homo/cmd/webview/config"
+	"github.com/countstarlight/homo/module/diag"
+	"github.com/countstarlight/homo/module/health"
 	"github.com/countstarlight/homo/module/sphinx"
+	"github.com/countstarlight/homo/module/trace"
 	"github.com/countstarlight/homo/module/view"
 	"github.com/sirupsen/logrus"
 	"github.com/urfave/cli"
 	"os"
 	"runtime"
 	"strings"
+	"time"
 )
 
 func init() {
@@ -51,6 +55,24 @@ var flags = []cli.Flag{
 		Usage:       "can interrupt the playing voice",
 		Destination: &config.InterruptMode,
 	},
+	cli.StringFlag{
+		EnvVar:      "HOMO_PROFILE",
+		Name:        "profile, p",
+		Usage:       "use named config profile, eg. sections like [log.prod] for 'prod'",
+		Destination: &config.Profile,
+	},
+	cli.StringFlag{
+		EnvVar:      "HOMO_CONFIG",
+		Name:        "config, c",
+		Usage:       "config file in ini, json or toml format, default: conf/app.ini, conf/app.json or conf/app.toml",
+		Destination: &config.ConfFile,
+	},
+	cli.BoolFlag{
+		EnvVar:      "HOMO_WEBVIEW_DRY_RUN",
+		Name:        "dry-run",
+		Usage:       "check config and environment then exit without starting",
+		Destination: &config.DryRun,
+	},
 }
 
 // Greeting list
@@ -63,14 +85,27 @@ func main() {
 	app.Usage = "Help"
 	app.Action = lanchWebview
 	app.Flags = flags
+	app.Commands = commands
 	app.Before = before
-	app.After = config.Terminal
+	app.After = terminal
 	if err := app.Run(os.Args); err != nil {
 		logrus.Fatalf("[homo-webview]%s", err.Error())
 	}
 }
 
-func lanchWebview(ctx *cli.Context) {
+// terminal logs latency summary of this run before config.Terminal,
+// it can be read while running from /v1/debug/latency of diagnostics server.
+func terminal(c *cli.Context) error {
+	for _, line := range trace.Summary() {
+		logrus.Infof("延迟统计 %s", line)
+	}
+	return config.Terminal(c)
+}
+
+func lanchWebview(ctx *cli.Context) error {
+	if config.DryRun {
+		return dryRun()
+	}
 	if ctx.Bool("debug") {
 		config.DebugMode = true
 		// Set logrus format
@@ -88,6 +123,7 @@ func lanchWebview(ctx *cli.Context) {
 				return fmt.Sprintf("%s()", r[len(r)-1]), fmt.Sprintf("%s:%d", filename, f.Line)
 			},
 		})
+		logrus.SetLevel(logrus.DebugLevel)
 		logrus.Infof("Running in debug mode")
 	} else {
 		logrus.SetFormatter(&logrus.TextFormatter{
@@ -100,7 +136,25 @@ func lanchWebview(ctx *cli.Context) {
 
 	if config.OfflineMode {
 		logrus.Warnf("注意：当前处于离线模式，语音识别和语音合成将不可用")
+	} else if config.HealthInterval > 0 {
+		// Check external dependencies periodically
+		go health.Watch(config.ExternalDeps,
+			time.Duration(config.HealthInterval)*time.Second,
+			time.Duration(config.HealthTimeout)*time.Second)
 	}
+	// Diagnostics server of pprof and runtime state
+	if len(config.DebugAddr) > 0 {
+		go func() {
+			logrus.Infof("诊断服务监听于 %s", config.DebugAddr)
+			if err := diag.Serve(config.DebugAddr, config.DebugToken); err != nil {
+				logrus.Errorf("诊断服务出错: %s", err.Error())
+			}
+		}()
+	}
+
+	// Reload config on SIGHUP
+	go config.WatchReload()
+
 	// Init webview
 	view.InitWebView(config.AppName, config.DebugMode)
 	//defer w.Exit()
@@ -124,16 +178,39 @@ func lanchWebview(ctx *cli.Context) {
 	go func() {
 		//Greeting := Greetings[rand.Intn(len(Greetings))]
 		if !config.OfflineMode {
-			view.SendReplyWithVoice(Greetings)
+			view.SendReplyWithVoice(Greetings, nil)
 		} else {
 			view.SendReply(Greetings)
 		}
 	}()
 
 	view.Run()
+	return nil
+}
+
+// dryRun checks config and environment, all problems found are printed.
+// It runs as action, error returned by app.Before would print help after them.
+func dryRun() error {
+	errs := config.CheckConfig()
+	for _, err := range errs {
+		logrus.Error(err.Error())
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("检查配置发现 %d 个问题", len(errs))
+	}
+	logrus.Infof("配置文件 %s 检查通过", config.ConfFile)
+	return nil
 }
 
 func before(c *cli.Context) error {
+	// Subcommands don't need config loaded
+	if c.NArg() > 0 && c.App.Command(c.Args().First()) != nil {
+		return nil
+	}
+	// Checked in action
+	if config.DryRun {
+		return nil
+	}
 	config.LoadConfig()
 	return nil
 }
@@ -0,0 +1,135 @@
+//
+// Copyright (c) 2019-present Codist <countstarlight@gmail.com>. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+// Written by Codist <countstarlight@gmail.com>, October 2026
+//
+
+package audio
+
+import (
+	"fmt"
+	"github.com/xlab/portaudio-go/portaudio"
+	"sync/atomic"
+	"unsafe"
+)
+
+const (
+	// Default input device of PortAudio
+	DefaultDevice = -1
+	// Buffered frames before dropping, about 8 seconds of 512 samples at 16000Hz
+	captureBufferSize = 256
+)
+
+// Capture reads 16 bit mono audio from microphone into Frames,
+// reading is done in PortAudio callback so it never blocks on consumer.
+type Capture struct {
+	Frames chan []int16
+
+	stream    *portaudio.Stream
+	noiseGate int16
+	gate      func() bool
+	dropped   uint64
+}
+
+// OpenCapture opens input device with sample rate, device is index of PortAudio
+// device or DefaultDevice, frames whose peak amplitude is lower than noiseGate
+// are muted, 0 to disable noise gating. Frames captured while gate returns false
+// are discarded in callback and never queued, gate can be nil.
+func OpenCapture(device, sampleRate, samplesPerFrame, noiseGate int, gate func() bool) (*Capture, error) {
+	if device == DefaultDevice {
+		device = int(portaudio.GetDefaultInputDevice())
+	}
+	if device < 0 || device >= int(portaudio.GetDeviceCount()) {
+		return nil, fmt.Errorf("PortAudio input device %d not found", device)
+	}
+	info := portaudio.GetDeviceInfo(portaudio.DeviceIndex(device))
+	if info.MaxInputChannels < 1 {
+		return nil, fmt.Errorf("PortAudio device %d [%s] has no input channel", device, info.Name)
+	}
+
+	c := &Capture{
+		Frames:    make(chan []int16, captureBufferSize),
+		noiseGate: int16(noiseGate),
+		gate:      gate,
+	}
+	params := &portaudio.StreamParameters{
+		Device:           portaudio.DeviceIndex(device),
+		ChannelCount:     1,
+		SampleFormat:     portaudio.PaInt16,
+		SuggestedLatency: info.DefaultLowInputLatency,
+	}
+	if err := portaudio.OpenStream(&c.stream, params, nil, float64(sampleRate), uint(samplesPerFrame),
+		portaudio.PaNoFlag, c.paCallback, nil); PaError(err) {
+		return nil, fmt.Errorf("PortAudio open device %d [%s] failed: %s", device, info.Name, PaErrorText(err))
+	}
+	return c, nil
+}
+
+// Start starts capturing audio.
+func (c *Capture) Start() error {
+	if err := portaudio.StartStream(c.stream); PaError(err) {
+		return fmt.Errorf("PortAudio start stream failed: %s", PaErrorText(err))
+	}
+	return nil
+}
+
+// Close stops capturing and closes the device, Frames is closed after it.
+func (c *Capture) Close() error {
+	if err := portaudio.StopStream(c.stream); PaError(err) {
+		return fmt.Errorf("PortAudio stop stream failed: %s", PaErrorText(err))
+	}
+	// Callback will not be called after stream stopped
+	close(c.Frames)
+	if err := portaudio.CloseStream(c.stream); PaError(err) {
+		return fmt.Errorf("PortAudio close stream failed: %s", PaErrorText(err))
+	}
+	return nil
+}
+
+// Dropped returns count of frames dropped because consumer is too slow.
+func (c *Capture) Dropped() uint64 {
+	return atomic.LoadUint64(&c.dropped)
+}
+
+func (c *Capture) paCallback(input unsafe.Pointer, _ unsafe.Pointer, sampleCount uint,
+	_ *portaudio.StreamCallbackTimeInfo, _ portaudio.StreamCallbackFlags, _ unsafe.Pointer) int32 {
+
+	// Checked in real time, or frames queued during gate closed would be consumed after it opened
+	if c.gate != nil && !c.gate() {
+		return int32(portaudio.PaContinue)
+	}
+
+	// Input buffer is reused by PortAudio, copy it out
+	in := (*(*[1 << 24]int16)(input))[:int(sampleCount)]
+	frame := make([]int16, len(in))
+	copy(frame, in)
+
+	if c.noiseGate > 0 && peak(frame) < int(c.noiseGate) {
+		for i := range frame {
+			frame[i] = 0
+		}
+	}
+
+	select {
+	case c.Frames <- frame:
+	default:
+		atomic.AddUint64(&c.dropped, 1)
+	}
+	return int32(portaudio.PaContinue)
+}
+
+// peak returns max absolute amplitude of frame.
+func peak(frame []int16) int {
+	max := 0
+	for _, s := range frame {
+		v := int(s)
+		if v < 0 {
+			v = -v
+		}
+		if v > max {
+			max = v
+		}
+	}
+	return max
+}
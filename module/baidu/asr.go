@@ -16,7 +16,6 @@ import (
 	"github.com/countstarlight/homo/module/com"
 	"io"
 	"io/ioutil"
-	"net"
 	"net/http"
 	"os"
 )
@@ -96,23 +95,11 @@ func (vc *VoiceClient) SpeechToText(reader io.Reader, params ...ASRParam) ([]str
 
 	spch := base64.StdEncoding.EncodeToString(content)
 
-	var cuid string
-	netitfs, err := net.Interfaces()
-	if err != nil {
-		cuid = "anonymous"
-	} else {
-		for _, itf := range netitfs {
-			if cuid = itf.HardwareAddr.String(); len(cuid) > 0 {
-				break
-			}
-		}
-	}
-
 	asrParams := &ASRParams{
 		Format:   "pcm",
 		Rate:     8000,
 		Channel:  1,
-		Cuid:     cuid,
+		Cuid:     CUID(),
 		Token:    vc.AccessToken,
 		Language: "zh",
 		Speech:   spch,
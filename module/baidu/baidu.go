@@ -11,8 +11,15 @@ import (
 	"encoding/json"
 	"fmt"
 	"github.com/countstarlight/homo/cmd/webview/config"
+	"net"
 	"net/http"
 	"net/url"
+	"sync"
+)
+
+var (
+	cuid     string
+	cuidOnce sync.Once
 )
 
 // Authorizer 用于设置access_token
@@ -85,3 +92,22 @@ func NewClient(ApiKey, secretKey string) *Client {
 		Authorizer:   DefaultAuthorizer{},
 	}
 }
+
+// CUID returns user unique identity for baidu api, the first hardware address
+// of network interfaces, it never changes so only collected once.
+func CUID() string {
+	cuidOnce.Do(func() {
+		netitfs, err := net.Interfaces()
+		if err == nil {
+			for _, itf := range netitfs {
+				if cuid = itf.HardwareAddr.String(); len(cuid) > 0 {
+					break
+				}
+			}
+		}
+		if len(cuid) == 0 {
+			cuid = "anonymous"
+		}
+	})
+	return cuid
+}
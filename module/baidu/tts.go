@@ -13,7 +13,6 @@ import (
 	"github.com/countstarlight/homo/module/audio"
 	"github.com/countstarlight/homo/module/com"
 	"io/ioutil"
-	"net"
 	"net/http"
 	"net/url"
 	"os"
@@ -30,22 +29,10 @@ func (vc *VoiceClient) TextToSpeech(txt string) ([]byte, error) {
 		return nil, err
 	}
 
-	var cuid string
-	netitfs, err := net.Interfaces()
-	if err != nil {
-		cuid = "anonymous"
-	} else {
-		for _, itf := range netitfs {
-			if cuid = itf.HardwareAddr.String(); len(cuid) > 0 {
-				break
-			}
-		}
-	}
-
 	resp, err := http.PostForm(config.BaiduTTSAPI, url.Values{
 		"tex":  {txt},
 		"tok":  {vc.AccessToken},
-		"cuid": {cuid},
+		"cuid": {CUID()},
 		"ctp":  {"1"},
 		"lan":  {"zh"},
 		"spd":  {"5"},
@@ -0,0 +1,68 @@
+//
+// Copyright (c) 2019-present Codist <countstarlight@gmail.com>. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+// Written by Codist <countstarlight@gmail.com>, October 2026
+//
+
+package com
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+)
+
+func TestEncryptDecrypt(t *testing.T) {
+	key := DeriveKey([]byte("secret"))
+	for _, text := range []string{"", "0vWCVCLsbWHMSH1wjvxaDq4VmvCZM2O9", "密码 %(x)s ${HOME}"} {
+		data, err := Encrypt(key, text)
+		if err != nil {
+			t.Fatalf("Encrypt(%q): unexpected error %v", text, err)
+		}
+		got, err := Decrypt(key, data)
+		if err != nil {
+			t.Errorf("Decrypt(%q): unexpected error %v", data, err)
+			continue
+		}
+		if got != text {
+			t.Errorf("Decrypt(Encrypt(%q)) = %q", text, got)
+		}
+	}
+
+	// Nonce is random, same text is never encrypted to same data
+	a, _ := Encrypt(key, "text")
+	b, _ := Encrypt(key, "text")
+	if a == b {
+		t.Errorf("Encrypt returned same data twice: %s", a)
+	}
+}
+
+func TestDecryptError(t *testing.T) {
+	key := DeriveKey([]byte("secret"))
+	data, err := Encrypt(key, "text")
+	if err != nil {
+		t.Fatalf("Encrypt: unexpected error %v", err)
+	}
+	raw, _ := base64.StdEncoding.DecodeString(data)
+
+	tests := []struct {
+		name string
+		key  []byte
+		data string
+		err  string
+	}{
+		{"wrong key", DeriveKey([]byte("other")), data, "cipher: message authentication failed"},
+		{"truncated nonce", key, base64.StdEncoding.EncodeToString(raw[:8]), "cipher text too short"},
+		{"truncated tag", key, base64.StdEncoding.EncodeToString(raw[:len(raw)-1]), "cipher: message authentication failed"},
+		{"tampered", key, base64.StdEncoding.EncodeToString(append(raw[:len(raw)-1:len(raw)-1], raw[len(raw)-1]^1)), "cipher: message authentication failed"},
+		{"bad base64", key, "not base64!", "illegal base64 data"},
+		{"bad key size", []byte("short"), data, "crypto/aes: invalid key size"},
+	}
+	for _, tt := range tests {
+		got, err := Decrypt(tt.key, tt.data)
+		if err == nil || !strings.HasPrefix(err.Error(), tt.err) {
+			t.Errorf("%s: Decrypt() = %q, %v, want error %q", tt.name, got, err, tt.err)
+		}
+	}
+}
@@ -7,7 +7,10 @@
 
 package com
 
-import "os"
+import (
+	"io/ioutil"
+	"os"
+)
 
 // IsFile returns true if given path is a file,
 // or returns false when it's a directory or does not exist.
@@ -18,3 +21,26 @@ func IsFile(filePath string) bool {
 	}
 	return !f.IsDir()
 }
+
+// IsWritable returns true if given path can be written,
+// a directory is checked by creating a temporary file in it.
+func IsWritable(path string) bool {
+	f, e := os.Stat(path)
+	if e != nil {
+		return false
+	}
+	if !f.IsDir() {
+		file, err := os.OpenFile(path, os.O_WRONLY, 0)
+		if err != nil {
+			return false
+		}
+		IOClose("writable check "+path, file)
+		return true
+	}
+	tmp, err := ioutil.TempFile(path, ".homo-")
+	if err != nil {
+		return false
+	}
+	IOClose("writable check "+tmp.Name(), tmp)
+	return os.Remove(tmp.Name()) == nil
+}
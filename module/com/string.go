@@ -7,7 +7,10 @@
 
 package com
 
-import "os"
+import (
+	"os"
+	"strings"
+)
 
 // IfStringInArray return whether a string in string array
 func IfStringInArray(a string, list []string) bool {
@@ -27,3 +30,46 @@ func PathExists(path string) bool {
 	}
 	return !os.IsNotExist(err)
 }
+
+// ExpandEnv replaces ${var} in s with value of environment variable,
+// ${var:-default} is replaced with default if var is unset or empty,
+// use $$ for a literal $. Other $ like in "p@ss$word" are kept as is.
+func ExpandEnv(s string) string {
+	if !strings.Contains(s, "$") {
+		return s
+	}
+	var buf strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '$' || i+1 == len(s) {
+			buf.WriteByte(s[i])
+			continue
+		}
+		switch s[i+1] {
+		case '$':
+			buf.WriteByte('$')
+			i++
+		case '{':
+			end := strings.IndexByte(s[i+2:], '}')
+			if end < 0 {
+				buf.WriteByte(s[i])
+				continue
+			}
+			buf.WriteString(lookupEnv(s[i+2 : i+2+end]))
+			i += end + 2
+		default:
+			buf.WriteByte(s[i])
+		}
+	}
+	return buf.String()
+}
+
+// lookupEnv returns value of "var" or "var:-default"
+func lookupEnv(key string) string {
+	if i := strings.Index(key, ":-"); i >= 0 {
+		if v := os.Getenv(key[:i]); len(v) > 0 {
+			return v
+		}
+		return key[i+2:]
+	}
+	return os.Getenv(key)
+}
@@ -0,0 +1,49 @@
+//
+// Copyright (c) 2019-present Codist <countstarlight@gmail.com>. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+// Written by Codist <countstarlight@gmail.com>, October 2026
+//
+
+package com
+
+import (
+	"os"
+	"testing"
+)
+
+func TestExpandEnv(t *testing.T) {
+	os.Setenv("HOMO_TEST_X", "x")
+	os.Setenv("HOMO_TEST_EMPTY", "")
+	os.Unsetenv("HOMO_TEST_UNSET")
+	defer os.Unsetenv("HOMO_TEST_X")
+	defer os.Unsetenv("HOMO_TEST_EMPTY")
+
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"no dollar", "/data/homo", "/data/homo"},
+		{"braced", "${HOMO_TEST_X}/a", "x/a"},
+		{"repeated", "${HOMO_TEST_X}${HOMO_TEST_X}z", "xxz"},
+		{"unset", "a${HOMO_TEST_UNSET}b", "ab"},
+		{"default if unset", "${HOMO_TEST_UNSET:-def}", "def"},
+		{"default if empty", "${HOMO_TEST_EMPTY:-def}", "def"},
+		{"default not used", "${HOMO_TEST_X:-def}", "x"},
+		{"empty default", "${HOMO_TEST_UNSET:-}", ""},
+		{"escaped", "$${HOMO_TEST_X}", "${HOMO_TEST_X}"},
+		{"escaped bare", "$$HOMO_TEST_X", "$HOMO_TEST_X"},
+		{"bare kept", "$HOMO_TEST_X", "$HOMO_TEST_X"},
+		{"password", "p@ss$word", "p@ss$word"},
+		{"digit", "a$1b", "a$1b"},
+		{"trailing", "a$", "a$"},
+		{"unclosed", "${HOMO_TEST_X", "${HOMO_TEST_X"},
+		{"percent", "%(x)s${HOMO_TEST_X}", "%(x)sx"},
+	}
+	for _, tt := range tests {
+		if got := ExpandEnv(tt.in); got != tt.want {
+			t.Errorf("%s: ExpandEnv(%q) = %q, want %q", tt.name, tt.in, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,110 @@
+//
+// Copyright (c) 2019-present Codist <countstarlight@gmail.com>. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+// Written by Codist <countstarlight@gmail.com>, October 2026
+//
+
+package diag
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"github.com/countstarlight/homo/module/trace"
+	"net/http"
+	"net/http/pprof"
+	"runtime"
+	"strings"
+	"time"
+)
+
+var startTime = time.Now()
+
+// Runtime is the state of current process returned by /v1/debug/runtime
+type Runtime struct {
+	Uptime     string `json:"uptime"`
+	Goroutines int    `json:"goroutines"`
+	CGoCalls   int64  `json:"cgo_calls"`
+
+	// Memory in bytes
+	HeapAlloc   uint64 `json:"heap_alloc"`
+	HeapInuse   uint64 `json:"heap_inuse"`
+	HeapObjects uint64 `json:"heap_objects"`
+	Sys         uint64 `json:"sys"`
+
+	NumGC        uint32 `json:"num_gc"`
+	LastGC       string `json:"last_gc"`
+	LastPause    string `json:"last_pause"`
+	PauseTotal   string `json:"pause_total"`
+	OpenFiles    int    `json:"open_files"`     // -1 if unknown
+	MaxOpenFiles int64  `json:"max_open_files"` // -1 if unknown
+}
+
+// Serve serves pprof under /debug/pprof/, runtime state under /v1/debug/runtime and
+// latency percentiles of traced stages under /v1/debug/latency on addr, requests must carry token as "Authorization: Bearer <token>",
+// all requests are rejected if token is empty.
+func Serve(addr, token string) error {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	mux.HandleFunc("/v1/debug/runtime", handleRuntime)
+	mux.HandleFunc("/v1/debug/latency", handleLatency)
+	return http.ListenAndServe(addr, auth(token, mux))
+}
+
+// ReadRuntime returns current state of process
+func ReadRuntime() Runtime {
+	var m runtime.MemStats
+	runtime.ReadMemStats(&m)
+	r := Runtime{
+		Uptime:      time.Since(startTime).Round(time.Second).String(),
+		Goroutines:  runtime.NumGoroutine(),
+		CGoCalls:    runtime.NumCgoCall(),
+		HeapAlloc:   m.HeapAlloc,
+		HeapInuse:   m.HeapInuse,
+		HeapObjects: m.HeapObjects,
+		Sys:         m.Sys,
+		NumGC:       m.NumGC,
+		PauseTotal:  time.Duration(m.PauseTotalNs).String(),
+	}
+	if m.NumGC > 0 {
+		r.LastGC = time.Unix(0, int64(m.LastGC)).Format(time.RFC3339)
+		r.LastPause = time.Duration(m.PauseNs[(m.NumGC+255)%256]).String()
+	}
+	r.OpenFiles, r.MaxOpenFiles = openFiles()
+	return r
+}
+
+func handleRuntime(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, ReadRuntime())
+}
+
+func handleLatency(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, trace.Summary())
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// auth requires "Authorization: Bearer <token>", token is never accepted in
+// query to keep it out of urls, shell history and proxy logs.
+func auth(token string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		h := r.Header.Get("Authorization")
+		if len(token) == 0 || !strings.HasPrefix(h, "Bearer ") ||
+			subtle.ConstantTimeCompare([]byte(strings.TrimPrefix(h, "Bearer ")), []byte(token)) != 1 {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
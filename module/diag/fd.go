@@ -0,0 +1,40 @@
+//
+// Copyright (c) 2019-present Codist <countstarlight@gmail.com>. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+// Written by Codist <countstarlight@gmail.com>, October 2026
+//
+
+//go:build !windows
+// +build !windows
+
+package diag
+
+import (
+	"os"
+	"syscall"
+)
+
+// openFiles returns count and soft limit of open file descriptors, -1 if unknown.
+func openFiles() (int, int64) {
+	count, limit := -1, int64(-1)
+	// /dev/fd on darwin
+	for _, dir := range []string{"/proc/self/fd", "/dev/fd"} {
+		f, err := os.Open(dir)
+		if err != nil {
+			continue
+		}
+		// Names only, fds may be closed while reading, including the one of dir
+		names, err := f.Readdirnames(-1)
+		_ = f.Close()
+		if err == nil {
+			count = len(names) - 1
+			break
+		}
+	}
+	var rl syscall.Rlimit
+	if err := syscall.Getrlimit(syscall.RLIMIT_NOFILE, &rl); err == nil {
+		limit = int64(rl.Cur)
+	}
+	return count, limit
+}
@@ -0,0 +1,13 @@
+//
+// Copyright (c) 2019-present Codist <countstarlight@gmail.com>. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+// Written by Codist <countstarlight@gmail.com>, October 2026
+//
+
+package diag
+
+// openFiles is not supported on windows.
+func openFiles() (int, int64) {
+	return -1, -1
+}
@@ -0,0 +1,152 @@
+//
+// Copyright (c) 2019-present Codist <countstarlight@gmail.com>. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+// Written by Codist <countstarlight@gmail.com>, October 2026
+//
+
+package health
+
+import (
+	"context"
+	"fmt"
+	"github.com/sirupsen/logrus"
+	"net"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Target is an external dependency, Addr can be an url like http://vop.baidu.com/server_api,
+// a host:port, or a host only which is checked by dns lookup only.
+type Target struct {
+	Name string
+	Addr string
+}
+
+// Status is the latest check result of a target
+type Status struct {
+	Target
+	Err     error     // nil if reachable
+	Since   time.Time // when status was changed to current one
+	Checked time.Time
+}
+
+var (
+	mutex    sync.RWMutex
+	statuses = make(map[string]*Status) // name -> status
+)
+
+// Watch checks targets returned by list every interval, logs when any of them
+// goes down or comes back, it never returns. List is called every round so that
+// targets changed by config reload are followed, status of removed ones is dropped.
+func Watch(list func() []Target, interval, timeout time.Duration) {
+	for {
+		targets := list()
+		for _, target := range targets {
+			update(target, Check(target, timeout))
+		}
+		prune(targets)
+		time.Sleep(interval)
+	}
+}
+
+// Check resolves host of target and connects to it if port is known
+func Check(target Target, timeout time.Duration) error {
+	host, port, err := splitAddr(target.Addr)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	addrs, err := net.DefaultResolver.LookupHost(ctx, host)
+	if err != nil {
+		return fmt.Errorf("域名解析失败: %s", err.Error())
+	}
+	if len(port) == 0 {
+		return nil
+	}
+	conn, err := net.DialTimeout("tcp", net.JoinHostPort(addrs[0], port), timeout)
+	if err != nil {
+		return fmt.Errorf("连接失败: %s", err.Error())
+	}
+	return conn.Close()
+}
+
+// Down returns error of the last check of target name,
+// nil if it's reachable or not checked yet.
+func Down(name string) error {
+	mutex.RLock()
+	defer mutex.RUnlock()
+	if s, ok := statuses[name]; ok {
+		return s.Err
+	}
+	return nil
+}
+
+// Hint returns a note to append to error message if target name is down,
+// so that upstream failure can be told from local one at a glance.
+func Hint(name string) string {
+	if err := Down(name); err != nil {
+		return fmt.Sprintf("（外部依赖 %s 不可达: %s）", name, err.Error())
+	}
+	return ""
+}
+
+func update(target Target, err error) {
+	mutex.Lock()
+	defer mutex.Unlock()
+	now := time.Now()
+	s, ok := statuses[target.Name]
+	if !ok || (s.Err == nil) != (err == nil) {
+		if err != nil {
+			logrus.Warnf("外部依赖 %s (%s) 不可用: %s", target.Name, target.Addr, err.Error())
+		} else if ok {
+			logrus.Infof("外部依赖 %s (%s) 已恢复，中断了 %s", target.Name, target.Addr, now.Sub(s.Since).Round(time.Second))
+		}
+		s = &Status{Target: target, Since: now}
+		statuses[target.Name] = s
+	}
+	s.Err = err
+	s.Checked = now
+}
+
+// prune drops status of targets not in list
+func prune(targets []Target) {
+	names := make(map[string]bool, len(targets))
+	for _, target := range targets {
+		names[target.Name] = true
+	}
+	mutex.Lock()
+	defer mutex.Unlock()
+	for name := range statuses {
+		if !names[name] {
+			delete(statuses, name)
+		}
+	}
+}
+
+// splitAddr returns host and port of addr, port of url is decided by scheme if not given
+func splitAddr(addr string) (host, port string, err error) {
+	if strings.Contains(addr, "://") {
+		u, err := url.Parse(addr)
+		if err != nil {
+			return "", "", err
+		}
+		host, port = u.Hostname(), u.Port()
+		if len(port) == 0 {
+			switch u.Scheme {
+			case "http", "ws":
+				port = "80"
+			case "https", "wss":
+				port = "443"
+			}
+		}
+		return host, port, nil
+	}
+	if !strings.Contains(addr, ":") {
+		return addr, "", nil
+	}
+	return net.SplitHostPort(addr)
+}
@@ -43,3 +43,15 @@ func ChatWithCore(text string) (string, error) {
 	}
 	return reply[0].Text, err
 }
+
+// Reply returns reply of text from nlu engine declared in pipeline config.
+func Reply(text string) ([]string, error) {
+	if config.CurrentSettings().PipelineNLU == "core" {
+		reply, err := ChatWithCore(text)
+		if err != nil {
+			return nil, err
+		}
+		return []string{reply}, nil
+	}
+	return ActionLocal(text)
+}
@@ -31,8 +31,8 @@ func Pcm2Wav(file string) error {
 	}
 	defer com.IOClose("Save file to "+config.InputWav, out)
 
-	// 16000 Hz, 16 bit, 1 channel, WAV.
-	e := wav.NewEncoder(out, sampleRate, 16, 1, 1)
+	// 16 bit, 1 channel, WAV.
+	e := wav.NewEncoder(out, config.SampleRate, 16, 1, 1)
 
 	// Create new audio.IntBuffer.
 	audioBuf, err := newAudioIntBuffer(in)
@@ -54,7 +54,7 @@ func newAudioIntBuffer(r io.Reader) (*audio.IntBuffer, error) {
 	buf := audio.IntBuffer{
 		Format: &audio.Format{
 			NumChannels: 1,
-			SampleRate:  sampleRate,
+			SampleRate:  config.SampleRate,
 		},
 	}
 	for {
@@ -14,21 +14,20 @@ import (
 	"github.com/countstarlight/homo/cmd/webview/config"
 	"github.com/countstarlight/homo/module/audio"
 	"github.com/countstarlight/homo/module/baidu"
+	"github.com/countstarlight/homo/module/com"
+	"github.com/countstarlight/homo/module/health"
 	"github.com/countstarlight/homo/module/nlu"
+	"github.com/countstarlight/homo/module/trace"
 	"github.com/countstarlight/homo/module/view"
 	"github.com/sirupsen/logrus"
 	"github.com/xlab/pocketsphinx-go/sphinx"
-	"github.com/xlab/portaudio-go/portaudio"
 	"io/ioutil"
 	"unicode"
-	"unsafe"
 )
 
 const (
 	samplesPerChannel = 512
-	sampleRate        = 16000
 	channels          = 1
-	sampleFormat      = portaudio.PaInt16
 )
 
 type Listener struct {
@@ -38,13 +37,12 @@ type Listener struct {
 }
 
 func LoadCMUSphinx() {
-	config.SphinxLoop.Add(1)
 	// Init CMUSphinx
 	cfg := sphinx.NewConfig(
 		sphinx.HMMDirOption(config.HMMDirEn),
 		sphinx.DictFileOption(config.DictFileEn),
 		sphinx.LMFileOption(config.LMFileEn),
-		sphinx.SampleRateOption(sampleRate),
+		sphinx.SampleRateOption(float32(config.SampleRate)),
 	)
 	//Specify output dir for RAW recorded sound files (s16le). Directory must exist.
 	sphinx.RawLogDirOption(config.RawDir)(cfg)
@@ -65,52 +63,51 @@ func LoadCMUSphinx() {
 		dec: dec,
 	}
 
-	var stream *portaudio.Stream
-	errStr := portaudio.OpenDefaultStream(&stream, channels, 0, sampleFormat, sampleRate, samplesPerChannel, l.paCallback, nil)
-	if audio.PaError(errStr) {
-		logrus.Fatalf("PortAudio failed: %s", audio.PaErrorText(errStr))
+	// Do not record if is playing voice, or the reply would be recognized as input
+	capture, err := audio.OpenCapture(config.AudioDevice, config.SampleRate, samplesPerChannel, config.NoiseGate, func() bool {
+		return !config.IsPlayingVoice || config.InterruptMode
+	})
+	if err != nil {
+		logrus.Fatalf("打开麦克风失败: %s", err.Error())
 	}
 	defer func() {
-		if errStr := portaudio.CloseStream(stream); audio.PaError(errStr) {
-			logrus.Warnf("PortAudio error:", audio.PaErrorText(errStr))
+		if err := capture.Close(); err != nil {
+			logrus.Warnf("关闭麦克风失败: %s", err.Error())
 		}
 	}()
-	if errStr := portaudio.StartStream(stream); audio.PaError(errStr) {
-		logrus.Fatalf("PortAudio error: %s", audio.PaErrorText(errStr))
+	if err := capture.Start(); err != nil {
+		logrus.Fatalf("打开麦克风失败: %s", err.Error())
 	}
-	defer func() {
-		if errStr := portaudio.StopStream(stream); audio.PaError(errStr) {
-			logrus.Fatalf("PortAudio error:", audio.PaErrorText(errStr))
-		}
-	}()
 	if !dec.StartUtt() {
 		logrus.Fatalln("Sphinx failed to start utterance")
 	}
-	logrus.Infof("开始从麦克风检测唤醒词：采样率[%dHz] 通道数[%d]", sampleRate, channels)
-	config.SphinxLoop.Wait()
+	logrus.Infof("开始从麦克风检测唤醒词：采样率[%dHz] 通道数[%d]", config.SampleRate, channels)
+	var dropped uint64
+	for frame := range capture.Frames {
+		if !l.process(frame) {
+			logrus.Errorf("Sphinx failed to process audio, %d frames dropped", capture.Dropped())
+			return
+		}
+		if d := capture.Dropped(); d > dropped {
+			logrus.Warnf("音频处理过慢，丢弃了 %d 帧音频", d-dropped)
+			dropped = d
+		}
+	}
 }
 
-// paCallback: for simplicity reasons we process raw audio with sphinx in the this stream callback,
-// never do that for any serious applications, use a buffered channel instead.
-func (l *Listener) paCallback(input unsafe.Pointer, _ unsafe.Pointer, sampleCount uint,
-	_ *portaudio.StreamCallbackTimeInfo, _ portaudio.StreamCallbackFlags, _ unsafe.Pointer) int32 {
-
-	const (
-		statusContinue = int32(portaudio.PaContinue)
-		statusAbort    = int32(portaudio.PaAbort)
-	)
-
+// process runs voice activity detection on a captured frame,
+// speech segment is reported when speech -> silence transition.
+func (l *Listener) process(in []int16) bool {
 	// Do not record if is playing voice
 	if config.IsPlayingVoice && !config.InterruptMode {
-		return statusContinue
+		return true
 	}
 
-	in := (*(*[1 << 24]int16)(input))[:int(sampleCount)*channels]
-	// ProcessRaw with disabled search because callback needs to be relatime
+	// ProcessRaw with disabled search, search is done when utterance ended
 	_, ok := l.dec.ProcessRaw(in, true, false)
 	// log.Printf("processed: %d frames, ok: %v", frames, ok)
 	if !ok {
-		return statusAbort
+		return false
 	}
 
 	if l.dec.IsInSpeech() {
@@ -132,11 +129,12 @@ func (l *Listener) paCallback(input unsafe.Pointer, _ unsafe.Pointer, sampleCoun
 			logrus.Fatalln("Sphinx failed to start utterance")
 		}
 	}
-	return statusContinue
+	return true
 }
 
 func (l *Listener) report() {
 	if config.WakeUpd {
+		t := trace.New("voice")
 		//Display typing animate during asr
 		if !config.SilenceMode {
 			view.TypingAnimate()
@@ -154,8 +152,8 @@ func (l *Listener) report() {
 		}
 
 		//Reduce sensitivity
-		if len(buf.Bytes()) < config.RecordThreshold {
-			logrus.Infof("音频长度小于阈值 %d，取消录制", config.RecordThreshold)
+		if threshold := config.CurrentSettings().RecordThreshold; len(buf.Bytes()) < threshold {
+			logrus.Infof("音频长度小于阈值 %d，取消录制", threshold)
 			view.TypingAnimateStop()
 			return
 		}
@@ -169,7 +167,8 @@ func (l *Listener) report() {
 		// Speech to text
 		success := false
 		var errorMsg string
-		result, err := baidu.SpeechToText(config.InputRaw, "pcm", sampleRate)
+		result, err := baidu.SpeechToText(config.InputRaw, "pcm", config.SampleRate)
+		t.Stage("asr")
 		if err != nil {
 			if baidu.IsErrSpeechQuality(err) {
 				view.TypingAnimateStop()
@@ -178,7 +177,7 @@ func (l *Listener) report() {
 				//result = []string{"没有听清在说什么"}
 				//logrus.Warnf("没有听清在说什么")
 			} else {
-				errorMsg = fmt.Sprintf("语音在线识别出错：%s", err.Error())
+				errorMsg = fmt.Sprintf("语音在线识别出错：%s", err.Error()) + health.Hint(config.DepBaiduASR)
 				//logrus.Warnf("语音在线识别出错：%s", err.Error())
 			}
 		} else {
@@ -218,20 +217,26 @@ func (l *Listener) report() {
 			}
 			// If in silence mode, post message to nlu
 			if config.SilenceMode {
-				replyMessage, err := nlu.ActionLocal(message)
+				replyMessage, err := nlu.Reply(message)
 				if err != nil {
-					logrus.Warnf("连接到nlu出错: %s", err.Error())
+					logrus.Warnf("连接到nlu出错: %s%s", err.Error(), health.Hint(config.NLUDep()))
 				}
+				t.Stage("nlu")
+				// Silence mode may be switched off by nlu action
 				if !config.SilenceMode {
 					view.SendOnlyInputText(message)
 					if config.OfflineMode {
 						view.SendReply(replyMessage)
+						t.End()
 					} else {
-						view.SendReplyWithVoice(replyMessage)
+						view.SendReplyWithVoice(replyMessage, t)
 					}
+				} else {
+					t.End()
 				}
 			} else {
-				view.SendInputText(message)
+				// Trace is continued when webview posts the message back
+				view.SendInputText(message, t)
 			}
 		}
 
@@ -246,7 +251,7 @@ func (l *Listener) report() {
 	} else {
 		hyp, _ := l.dec.Hypothesis()
 		if len(hyp) > 0 {
-			if hyp == "homo" || hyp == "como" {
+			if com.IfStringInArray(hyp, config.WakeWords) {
 				logrus.Info("命中唤醒词，开始唤醒...")
 				config.WakeUpWait.Done()
 				config.WakeUpd = true
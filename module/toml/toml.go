@@ -0,0 +1,179 @@
+//
+// Copyright (c) 2019-present Codist <countstarlight@gmail.com>. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+// Written by Codist <countstarlight@gmail.com>, October 2026
+//
+
+// Package toml parses the subset of toml used by config files: tables with
+// strings, numbers, booleans and single line arrays of them.
+package toml
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Value is a key = value pair, Table is "" for keys before any table.
+// Items are string forms of the value, one item if it is not an array.
+type Value struct {
+	Table string
+	Key   string
+	Items []string
+	Array bool
+	Line  int
+}
+
+// Parse parses data into values in order of appearance, eg.
+//
+//	[pipeline]
+//	WAKE_WORDS = ["homo", "como"]
+//
+// is Value{Table: "pipeline", Key: "WAKE_WORDS", Items: []string{"homo", "como"}, Array: true}.
+func Parse(data []byte) ([]Value, error) {
+	var values []Value
+	table := ""
+	for i, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(stripComment(line))
+		if len(line) == 0 {
+			continue
+		}
+		if strings.HasPrefix(line, "[") {
+			if !strings.HasSuffix(line, "]") || strings.HasPrefix(line, "[[") {
+				return nil, fmt.Errorf("line %d: unsupported table %s", i+1, line)
+			}
+			table = strings.TrimSpace(line[1 : len(line)-1])
+			continue
+		}
+		eq := indexUnquoted(line, '=')
+		if eq < 0 {
+			return nil, fmt.Errorf("line %d: expect key = value: %s", i+1, line)
+		}
+		k, err := key(strings.TrimSpace(line[:eq]))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %s", i+1, err.Error())
+		}
+		v := Value{
+			Table: table,
+			Key:   k,
+			Line:  i + 1,
+		}
+		if len(v.Key) == 0 {
+			return nil, fmt.Errorf("line %d: empty key: %s", i+1, line)
+		}
+		raw := strings.TrimSpace(line[eq+1:])
+		if strings.HasPrefix(raw, "[") {
+			if !strings.HasSuffix(raw, "]") {
+				return nil, fmt.Errorf("line %d: multi-line array is not supported: %s", i+1, raw)
+			}
+			v.Array = true
+			for _, item := range splitArray(raw[1 : len(raw)-1]) {
+				s, err := scalar(item)
+				if err != nil {
+					return nil, fmt.Errorf("line %d: %s", i+1, err.Error())
+				}
+				v.Items = append(v.Items, s)
+			}
+		} else {
+			s, err := scalar(raw)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %s", i+1, err.Error())
+			}
+			v.Items = []string{s}
+		}
+		values = append(values, v)
+	}
+	return values, nil
+}
+
+// key returns name of a bare or quoted key. Dotted keys are not supported,
+// log.LEVEL = "warn" must be written as LEVEL = "warn" under [log].
+func key(s string) (string, error) {
+	if strings.HasPrefix(s, `"`) || strings.HasPrefix(s, "'") {
+		k, err := scalar(s)
+		if err != nil {
+			return "", fmt.Errorf("invalid key: %s", s)
+		}
+		return k, nil
+	}
+	if strings.Contains(s, ".") {
+		return "", fmt.Errorf("dotted key is not supported: %s", s)
+	}
+	return s, nil
+}
+
+// scalar returns string form of a string, number or boolean.
+func scalar(value string) (string, error) {
+	switch {
+	case strings.HasPrefix(value, "["):
+		return "", fmt.Errorf("nested array is not supported: %s", value)
+	case strings.HasPrefix(value, "{"):
+		return "", fmt.Errorf("inline table is not supported: %s", value)
+	case strings.HasPrefix(value, `"`):
+		s, err := strconv.Unquote(value)
+		if err != nil {
+			return "", fmt.Errorf("invalid string: %s", value)
+		}
+		return s, nil
+	case strings.HasPrefix(value, "'"):
+		if len(value) < 2 || !strings.HasSuffix(value, "'") || strings.Contains(value[1:len(value)-1], "'") {
+			return "", fmt.Errorf("invalid string: %s", value)
+		}
+		return value[1 : len(value)-1], nil
+	case value == "true" || value == "false":
+		return value, nil
+	}
+	number := strings.Replace(value, "_", "", -1)
+	if _, err := strconv.ParseFloat(number, 64); err != nil {
+		return "", fmt.Errorf("unsupported value: %s", value)
+	}
+	return number, nil
+}
+
+// stripComment removes comment starting with # not in a string.
+func stripComment(line string) string {
+	if i := indexUnquoted(line, '#'); i >= 0 {
+		return line[:i]
+	}
+	return line
+}
+
+// splitArray splits items of array by comma not in a string, trailing comma is allowed.
+func splitArray(s string) []string {
+	var items []string
+	for {
+		i := indexUnquoted(s, ',')
+		if i < 0 {
+			break
+		}
+		items = append(items, strings.TrimSpace(s[:i]))
+		s = s[i+1:]
+	}
+	if last := strings.TrimSpace(s); len(last) > 0 {
+		items = append(items, last)
+	}
+	return items
+}
+
+// indexUnquoted returns index of the first sep not in a string, or -1.
+func indexUnquoted(s string, sep byte) int {
+	var quote byte
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote == '"' && c == '\\':
+			// Skip escaped char in basic string
+			i++
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == sep:
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,101 @@
+//
+// Copyright (c) 2019-present Codist <countstarlight@gmail.com>. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+// Written by Codist <countstarlight@gmail.com>, October 2026
+//
+
+package toml
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want []Value
+	}{
+		{
+			name: "tables",
+			data: "LEVEL = \"info\"\n[log]\nLEVEL = \"debug\"\n[ log.prod ]\nLEVEL = 'warn'\n",
+			want: []Value{
+				{Table: "", Key: "LEVEL", Items: []string{"info"}, Line: 1},
+				{Table: "log", Key: "LEVEL", Items: []string{"debug"}, Line: 3},
+				{Table: "log.prod", Key: "LEVEL", Items: []string{"warn"}, Line: 5},
+			},
+		},
+		{
+			name: "scalars",
+			data: "A = 16_000\nB = -0.5\nC = true\nD = \"a\\\"b\"\nE = 'C:\\tmp'\n\"F\" = \"x\"",
+			want: []Value{
+				{Key: "A", Items: []string{"16000"}, Line: 1},
+				{Key: "B", Items: []string{"-0.5"}, Line: 2},
+				{Key: "C", Items: []string{"true"}, Line: 3},
+				{Key: "D", Items: []string{`a"b`}, Line: 4},
+				{Key: "E", Items: []string{`C:\tmp`}, Line: 5},
+				{Key: "F", Items: []string{"x"}, Line: 6},
+			},
+		},
+		{
+			name: "arrays keep items",
+			data: "A = [\"a,b\", 'c', 1, ]\nB = []\n",
+			want: []Value{
+				{Key: "A", Items: []string{"a,b", "c", "1"}, Array: true, Line: 1},
+				{Key: "B", Array: true, Line: 2},
+			},
+		},
+		{
+			name: "comments",
+			data: "# comment\nA = \"#1\" # comment\nB = 'x=#' \n\n",
+			want: []Value{
+				{Key: "A", Items: []string{"#1"}, Line: 2},
+				{Key: "B", Items: []string{"x=#"}, Line: 3},
+			},
+		},
+	}
+	for _, tt := range tests {
+		got, err := Parse([]byte(tt.data))
+		if err != nil {
+			t.Errorf("%s: unexpected error %v", tt.name, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: got %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestParseError(t *testing.T) {
+	tests := []struct {
+		data string
+		err  string
+	}{
+		{"[[servers]]", "line 1: unsupported table"},
+		{"[log", "line 1: unsupported table"},
+		{"\nA", "line 2: expect key = value"},
+		{"= 1", "line 1: empty key"},
+		{"log.LEVEL = \"warn\"", "line 1: dotted key"},
+		{"[log]\nA.B = 1", "line 2: dotted key"},
+		{"\"log\".LEVEL = 1", "line 1: invalid key"},
+		{"A = {B = 1}", "line 1: inline table"},
+		{"A = [{B = 1}]", "line 1: inline table"},
+		{"A =", "line 1: unsupported value"},
+		{"A = [1,\n2]", "line 1: multi-line array"},
+		{"A = [[1]]", "line 1: nested array"},
+		{"A = \"a\" \"b\"", "line 1: invalid string"},
+		{"A = 'a", "line 1: invalid string"},
+		{"A = 'a'b'", "line 1: invalid string"},
+		{"A = yes", "line 1: unsupported value"},
+		{"A = [1,,2]", "line 1: unsupported value"},
+	}
+	for _, tt := range tests {
+		_, err := Parse([]byte(tt.data))
+		if err == nil || !strings.HasPrefix(err.Error(), tt.err) {
+			t.Errorf("Parse(%q) error = %v, want %q", tt.data, err, tt.err)
+		}
+	}
+}
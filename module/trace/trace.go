@@ -0,0 +1,114 @@
+//
+// Copyright (c) 2019-present Codist <countstarlight@gmail.com>. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+// Written by Codist <countstarlight@gmail.com>, October 2026
+//
+
+package trace
+
+import (
+	"fmt"
+	"github.com/sirupsen/logrus"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Latest samples kept for each stage to compute percentiles
+const maxSamples = 256
+
+var (
+	lastID  uint64
+	mutex   sync.Mutex
+	samples = make(map[string][]time.Duration) // "pipeline/stage" -> durations
+	keys    []string                           // keys of samples in order of first seen
+)
+
+// Trace records latency of each stage in one run of a pipeline,
+// all methods can be called on nil Trace and do nothing.
+type Trace struct {
+	ID       uint64
+	Pipeline string
+
+	start  time.Time
+	last   time.Time
+	stages []string
+	costs  []time.Duration
+}
+
+// New starts a trace of pipeline.
+func New(pipeline string) *Trace {
+	now := time.Now()
+	return &Trace{
+		ID:       atomic.AddUint64(&lastID, 1),
+		Pipeline: pipeline,
+		start:    now,
+		last:     now,
+	}
+}
+
+// Stage marks end of stage, its latency is the time since last stage ended.
+func (t *Trace) Stage(name string) {
+	if t == nil {
+		return
+	}
+	now := time.Now()
+	t.stages = append(t.stages, name)
+	t.costs = append(t.costs, now.Sub(t.last))
+	t.last = now
+}
+
+// End ends the trace and records latency of all stages.
+func (t *Trace) End() {
+	if t == nil {
+		return
+	}
+	total := time.Since(t.start)
+	var b strings.Builder
+	mutex.Lock()
+	for i, name := range t.stages {
+		record(t.Pipeline+"/"+name, t.costs[i])
+		fmt.Fprintf(&b, " %s[%s]", name, t.costs[i].Round(time.Millisecond))
+	}
+	record(t.Pipeline+"/total", total)
+	mutex.Unlock()
+	logrus.Debugf("trace %d %s:%s total[%s]", t.ID, t.Pipeline, b.String(), total.Round(time.Millisecond))
+}
+
+// record must be called with mutex held.
+func record(key string, d time.Duration) {
+	s, ok := samples[key]
+	if !ok {
+		keys = append(keys, key)
+	}
+	if len(s) >= maxSamples {
+		s = s[1:]
+	}
+	samples[key] = append(s, d)
+}
+
+// Summary returns p50, p90 and p99 latency of every pipeline stage recorded.
+func Summary() []string {
+	mutex.Lock()
+	defer mutex.Unlock()
+	lines := make([]string, 0, len(keys))
+	for _, key := range keys {
+		s := append([]time.Duration(nil), samples[key]...)
+		sort.Slice(s, func(i, j int) bool { return s[i] < s[j] })
+		lines = append(lines, fmt.Sprintf("%s: count[%d] p50[%s] p90[%s] p99[%s]", key, len(s),
+			percentile(s, 50), percentile(s, 90), percentile(s, 99)))
+	}
+	return lines
+}
+
+// percentile returns p-th percentile of sorted durations.
+func percentile(sorted []time.Duration, p int) time.Duration {
+	i := (len(sorted)*p+99)/100 - 1
+	if i < 0 {
+		i = 0
+	}
+	return sorted[i].Round(time.Millisecond)
+}
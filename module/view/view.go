@@ -16,7 +16,9 @@ import (
 	"github.com/countstarlight/homo/cmd/webview/config"
 	"github.com/countstarlight/homo/module/baidu"
 	"github.com/countstarlight/homo/module/com"
+	"github.com/countstarlight/homo/module/health"
 	"github.com/countstarlight/homo/module/nlu"
+	"github.com/countstarlight/homo/module/trace"
 	"github.com/sirupsen/logrus"
 	"github.com/zserge/webview"
 	"io"
@@ -26,11 +28,16 @@ import (
 	"net/http"
 	"path/filepath"
 	"strings"
+	"sync"
 	"time"
 )
 
 var (
 	w webview.WebView
+
+	// Trace of voice input sent to webview, continued when it is posted back
+	inputTrace      *trace.Trace
+	inputTraceMutex sync.Mutex
 )
 
 func InitWebView(title string, debug bool) {
@@ -57,7 +64,9 @@ func startServer() string {
 	}
 	go func() {
 		defer com.IOClose("webview ln", ln)
-		http.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		// Not DefaultServeMux, handlers registered by other packages like pprof are not exposed
+		mux := http.NewServeMux()
+		mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
 			path := r.URL.Path
 			if len(path) > 0 && path[0] == '/' {
 				path = path[1:]
@@ -76,7 +85,7 @@ func startServer() string {
 				}
 			}
 		})
-		log.Fatal(http.Serve(ln, nil))
+		log.Fatal(http.Serve(ln, mux))
 	}()
 	return "http://" + ln.Addr().String()
 }
@@ -106,7 +115,10 @@ func TypingAnimateStop() {
 	})
 }
 
-func SendInputText(message string) {
+func SendInputText(message string, t *trace.Trace) {
+	inputTraceMutex.Lock()
+	inputTrace = t
+	inputTraceMutex.Unlock()
 	w.Dispatch(func() {
 		err := w.Eval(fmt.Sprintf("chatWindow.InputText(\"%s\")", message))
 		if err != nil {
@@ -142,8 +154,13 @@ func SendReply(message []string) {
 	})
 }
 
-func SendReplyWithVoice(message []string) {
+// SendReplyWithVoice sends reply and plays it, t ends after playing, can be nil.
+func SendReplyWithVoice(message []string, t *trace.Trace) {
 	SendReply(message)
+	if config.CurrentSettings().PipelineTTS == "none" {
+		t.End()
+		return
+	}
 	//Play voice
 	time.Sleep(time.Second)
 	config.IsPlayingVoice = true
@@ -156,6 +173,8 @@ func SendReplyWithVoice(message []string) {
 		}
 	}
 	config.IsPlayingVoice = false
+	t.Stage("tts")
+	t.End()
 }
 
 func handleRPC(w webview.WebView, data string) {
@@ -165,17 +184,27 @@ func handleRPC(w webview.WebView, data string) {
 		//fmt.Printf("发送的消息: %s\n", msg)
 		//go TypingAnimate()
 		go func() {
+			inputTraceMutex.Lock()
+			t := inputTrace
+			inputTrace = nil
+			inputTraceMutex.Unlock()
+			if t == nil {
+				t = trace.New("text")
+			}
+
 			var reply []string
-			replyMessage, err := nlu.ActionLocal(msg)
+			replyMessage, err := nlu.Reply(msg)
 			if err != nil {
-				reply = []string{"错误: " + err.Error()}
+				reply = []string{"错误: " + err.Error() + health.Hint(config.NLUDep())}
 			} else {
 				reply = replyMessage
 			}
+			t.Stage("nlu")
 			if !config.OfflineMode {
-				SendReplyWithVoice(reply)
+				SendReplyWithVoice(reply, t)
 			} else {
 				SendReply(reply)
+				t.End()
 			}
 		}()
 	}